@@ -6,16 +6,31 @@
 package main
 
 import (
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+	"context"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tf5server"
 
 	"terraform-provider-for-vmware-cloud-director-availability/vcda"
 )
 
 func main() {
-	plugin.Serve(&plugin.ServeOpts{
-		ProviderFunc: func() *schema.Provider {
-			return vcda.Provider()
-		},
-	})
+	shutdownTracing, err := vcda.InitTracing(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	serverFactory, err := vcda.ProviderServerFactory(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = tf5server.Serve("registry.terraform.io/vmware/vcda", serverFactory)
+
+	if shutdownErr := shutdownTracing(context.Background()); shutdownErr != nil {
+		log.Printf("[ERROR] could not flush traces: %s", shutdownErr)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -7,11 +7,13 @@ package vcda
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
-	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 
@@ -22,43 +24,96 @@ import (
 )
 
 type Client struct {
-	VimClient     VimClient
-	VcdaIP        string
-	LocalUser     string
+	VcdaIP    string
+	LocalUser string
+	// LocalPassword is the configured local password. It is not changed
+	// after configuration, see localPasswordFor for the current password.
 	LocalPassword string
+
+	// CACertPool holds additional CA certificates trusted for the appliances.
+	CACertPool *x509.CertPool
+	// PinServiceCert makes the appliance connections trust exactly the
+	// service certificate, without verifying the chain or the host name.
+	PinServiceCert bool
+	// Auth holds the provider authentication settings, which the
+	// appliance settings may override.
+	Auth AuthConfig
+	// Appliances holds the per-appliance settings keyed by address.
+	Appliances map[string]ApplianceConfig
+	// APITimeout is the timeout of the vSphere inventory operations and of
+	// the certificate fetches from the appliances.
+	APITimeout time.Duration
+
+	// vimConfig holds the vSphere settings, or nil when the provider is
+	// configured without vSphere. The connection is established on first
+	// use, see vSphere.
+	vimConfig *Config
+	vim       vimConnection
+
+	serviceCerts serviceCertCache
+	credentials  credentialStore
+	commands     credentialCommands
 }
 
-func (c *Client) NewHTTPClientConfig(serviceCert string) (*http.Client, error) {
-	if serviceCert == "" {
-		return nil, fmt.Errorf("vcda service certificate is required")
+// NewHTTPClientConfig returns an HTTP client that trusts the service
+// certificate of the appliance at host. When serviceCert is empty, the
+// certificate is discovered through serviceCertFor.
+func (c *Client) NewHTTPClientConfig(ctx context.Context, host string, serviceCert string) (*http.Client, error) {
+	serviceCert, err := c.serviceCertFor(ctx, host, serviceCert)
+	if err != nil {
+		return nil, err
 	}
 
 	data, err := base64.StdEncoding.DecodeString(serviceCert)
 	if err != nil {
 		return nil, fmt.Errorf("could not decode vcda service certificate: %s", err)
 	}
-	block := &pem.Block{
-		Type:  "CERTIFICATE",
-		Bytes: data,
-	}
-	buf := new(bytes.Buffer)
-	if err := pem.Encode(buf, block); err != nil {
-		return nil, fmt.Errorf("could not encode vcda service certificate: %s", err)
-	}
 
-	caCertPool := x509.NewCertPool()
-	caCertPool.AppendCertsFromPEM(buf.Bytes())
+	var tlsConfig *tls.Config
+	if c.PinServiceCert {
+		tlsConfig = pinnedTLSConfig(sha256.Sum256(data))
+	} else {
+		cert, err := x509.ParseCertificate(data)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse vcda service certificate: %s", err)
+		}
+
+		caCertPool := x509.NewCertPool()
+		if c.CACertPool != nil {
+			caCertPool = c.CACertPool.Clone()
+		}
+		caCertPool.AddCert(cert)
+
+		tlsConfig = &tls.Config{RootCAs: caCertPool}
+	}
 
 	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{RootCAs: caCertPool},
+		TLSClientConfig: tlsConfig,
 	}
-	client := &http.Client{Timeout: 10 * time.Second, Transport: tr}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: &tracingTransport{next: &loggingTransport{next: tr}}}
 
 	return client, nil
 }
 
+// requestError wraps an error returned while executing a request to host,
+// explaining how to fix a failed certificate verification.
+func requestError(host string, err error) error {
+	if isCertificateError(err) {
+		return fmt.Errorf("could not verify the certificate of appliance %s: %s. Make sure service_cert is "+
+			"the certificate of the appliance, or enable vcda_certificate_pinning when the appliance is addressed "+
+			"by an IP address that is not part of its certificate", host, err)
+	}
+
+	return fmt.Errorf("error executing request: %s", err)
+}
+
 func (c *Client) DoRequest(host string, req *http.Request, serviceCert string) ([]byte, error) {
-	authToken, err := c.GetAuthToken(host, c.LocalPassword, serviceCert)
+	localPassword, err := c.localPasswordFor(host)
+	if err != nil {
+		return nil, err
+	}
+
+	authToken, err := c.GetAuthToken(req.Context(), host, localPassword, serviceCert)
 
 	if err != nil {
 		return nil, err
@@ -69,14 +124,14 @@ func (c *Client) DoRequest(host string, req *http.Request, serviceCert string) (
 	req.Header.Set(AcceptHeader, AcceptHeaderValue)
 	req.Header.Set(UserAgent, UserAgentValue)
 
-	hcl, err := c.NewHTTPClientConfig(serviceCert)
+	hcl, err := c.NewHTTPClientConfig(req.Context(), host, serviceCert)
 	if err != nil {
 		return nil, err
 	}
 
 	r, err := hcl.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error executing request: %s", err)
+		return nil, requestError(host, err)
 	}
 	defer r.Body.Close()
 
@@ -86,12 +141,35 @@ func (c *Client) DoRequest(host string, req *http.Request, serviceCert string) (
 	}
 
 	if !successCheck(r.StatusCode) {
-		return nil, fmt.Errorf("request: %s finished with status: %d, body: %s", req.URL.String(), r.StatusCode, body)
+		return nil, &apiError{URL: req.URL.String(), StatusCode: r.StatusCode, Body: body}
 	}
 
 	return body, err
 }
 
+// apiError is returned for the requests that finish with an error status.
+type apiError struct {
+	URL        string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("request: %s finished with status: %d, body: %s", e.URL, e.StatusCode, e.Body)
+}
+
+// isUnsupported reports whether err is returned for a request that the
+// appliance does not support.
+func isUnsupported(err error) bool {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusMethodNotAllowed ||
+		apiErr.StatusCode == http.StatusNotImplemented
+}
+
 func (c *Client) doRequest(req *http.Request, serviceCert string) ([]byte, error) {
 	return c.DoRequest(c.VcdaIP, req, serviceCert)
 }
@@ -122,21 +200,24 @@ func (c *Client) buildRequestURL(path string) (*string, error) {
 	return c.BuildRequestURL(c.VcdaIP, path)
 }
 
-func (c *Client) GetAuthToken(host string, password string, serviceCert string) (*string, error) {
+func (c *Client) GetAuthToken(ctx context.Context, host string, password string, serviceCert string) (*string, error) {
 	reqURL, err := c.BuildRequestURL(host, "/sessions")
 
 	if err != nil {
 		return nil, err
 	}
 
-	reqData := AuthTokenData{Type: UserType, LocalUser: c.LocalUser, LocalPassword: password}
+	reqData, err := c.authTokenData(host, password)
+	if err != nil {
+		return nil, err
+	}
 
 	rb, err := json.Marshal(reqData)
 	if err != nil {
 		return nil, fmt.Errorf("could not marshal request data: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 	req.Header.Set(ContentTypeHeader, ContentTypeHeaderValue)
 	req.Header.Set(AcceptHeader, AcceptHeaderValue)
 	req.Header.Set(UserAgent, UserAgentValue)
@@ -145,13 +226,19 @@ func (c *Client) GetAuthToken(host string, password string, serviceCert string)
 		return nil, err
 	}
 
-	hcl, err := c.NewHTTPClientConfig(serviceCert)
+	hcl, err := c.NewHTTPClientConfig(ctx, host, serviceCert)
 	if err != nil {
 		return nil, err
 	}
 	r, err := hcl.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error executing request: %s", err)
+		return nil, requestError(host, err)
+	}
+	defer r.Body.Close()
+
+	if !successCheck(r.StatusCode) {
+		body, _ := io.ReadAll(r.Body)
+		return nil, fmt.Errorf("%s authentication to %s failed with status: %d, body: %s", reqData.Type, host, r.StatusCode, body)
 	}
 
 	vcdaToken := r.Header.Get(VcdaAuthTokenHeader)
@@ -159,8 +246,42 @@ func (c *Client) GetAuthToken(host string, password string, serviceCert string)
 	return &vcdaToken, nil
 }
 
+// DeleteSession logs out the session of token at host.
+func (c *Client) DeleteSession(ctx context.Context, host string, token string, serviceCert string) error {
+	reqURL, err := c.BuildRequestURL(host, "/sessions")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, *reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating new request: %s", err)
+	}
+
+	req.Header.Set(VcdaAuthTokenHeader, token)
+	req.Header.Set(AcceptHeader, AcceptHeaderValue)
+	req.Header.Set(UserAgent, UserAgentValue)
+
+	hcl, err := c.NewHTTPClientConfig(ctx, host, serviceCert)
+	if err != nil {
+		return err
+	}
+	r, err := hcl.Do(req)
+	if err != nil {
+		return requestError(host, err)
+	}
+	defer r.Body.Close()
+
+	if !successCheck(r.StatusCode) && r.StatusCode != http.StatusUnauthorized {
+		body, _ := io.ReadAll(r.Body)
+		return fmt.Errorf("logout from %s failed with status: %d, body: %s", host, r.StatusCode, body)
+	}
+
+	return nil
+}
+
 // c4/h4 client methods
-func (c *Client) changePassword(host string, currentPassword string, newPassword string, serviceCert string) error {
+func (c *Client) changePassword(ctx context.Context, host string, currentPassword string, newPassword string, serviceCert string) error {
 	reqURL, err := c.BuildRequestURL(host, "/config/root-password")
 
 	if err != nil {
@@ -174,12 +295,12 @@ func (c *Client) changePassword(host string, currentPassword string, newPassword
 		return fmt.Errorf("could not marshal request data: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 	if err != nil {
 		return fmt.Errorf("error creating new request: %s", err)
 	}
 
-	token, err := c.GetAuthToken(host, currentPassword, serviceCert)
+	token, err := c.GetAuthToken(ctx, host, currentPassword, serviceCert)
 	if err != nil {
 		return err
 	}
@@ -190,13 +311,13 @@ func (c *Client) changePassword(host string, currentPassword string, newPassword
 	req.Header.Set(UserAgent, UserAgentValue)
 	req.Header.Set(ConfigSecretHeader, currentPassword)
 
-	hcl, err := c.NewHTTPClientConfig(serviceCert)
+	hcl, err := c.NewHTTPClientConfig(ctx, host, serviceCert)
 	if err != nil {
 		return err
 	}
 	r, err := hcl.Do(req)
 	if err != nil {
-		return fmt.Errorf("error executing request: %s", err)
+		return requestError(host, err)
 	}
 	defer r.Body.Close()
 
@@ -209,19 +330,19 @@ func (c *Client) changePassword(host string, currentPassword string, newPassword
 		return fmt.Errorf("change password failed with status: %d, body: %s", r.StatusCode, body)
 	}
 
-	c.LocalPassword = newPassword
+	c.setLocalPassword(host, newPassword)
 
 	return nil
 }
 
-func (c *Client) checkPasswordExpired(host string, serviceCert string) (*PasswordExpiration, error) {
+func (c *Client) checkPasswordExpired(ctx context.Context, host string, serviceCert string) (*PasswordExpiration, error) {
 	reqURL, err := c.BuildRequestURL(host, "/config/root-password-expired")
 
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodGet, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
@@ -241,7 +362,7 @@ func (c *Client) checkPasswordExpired(host string, serviceCert string) (*Passwor
 	return &rootExpiration, nil
 }
 
-func (c *Client) setLicense(serviceCert string, licenseKey string) (*License, error) {
+func (c *Client) setLicense(ctx context.Context, serviceCert string, licenseKey string) (*License, error) {
 	reqURL, err := c.buildRequestURL("/license")
 
 	if err != nil {
@@ -255,7 +376,7 @@ func (c *Client) setLicense(serviceCert string, licenseKey string) (*License, er
 		return nil, fmt.Errorf("could not marshal request data: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
@@ -275,7 +396,35 @@ func (c *Client) setLicense(serviceCert string, licenseKey string) (*License, er
 	return &vcdaLicense, nil
 }
 
-func (c *Client) setSiteName(siteName string, serviceCert string) (*SiteConfig, error) {
+func (c *Client) getLicense(ctx context.Context, serviceCert string) (*License, error) {
+	reqURL, err := c.buildRequestURL("/license")
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *reqURL, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating new request: %s", err)
+	}
+
+	body, err := c.doRequest(req, serviceCert)
+	if err != nil {
+		return nil, err
+	}
+
+	vcdaLicense := License{}
+	err = json.Unmarshal(body, &vcdaLicense)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal response body: %s", err)
+	}
+
+	return &vcdaLicense, nil
+}
+
+func (c *Client) setSiteName(ctx context.Context, siteName string, serviceCert string) (*SiteConfig, error) {
 	reqURL, err := c.buildRequestURL("/config/site")
 
 	if err != nil {
@@ -289,12 +438,17 @@ func (c *Client) setSiteName(siteName string, serviceCert string) (*SiteConfig,
 		return nil, fmt.Errorf("could not marshal request data: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
-	req.Header.Set(ConfigSecretHeader, c.LocalPassword)
+	configSecret, err := c.localPasswordFor(c.VcdaIP)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
+	req.Header.Set(ConfigSecretHeader, configSecret)
 
 	body, err := c.doRequest(req, serviceCert)
 	if err != nil {
@@ -311,7 +465,7 @@ func (c *Client) setSiteName(siteName string, serviceCert string) (*SiteConfig,
 	return &vcdaSite, nil
 }
 
-func (c *Client) setCloudSiteName(siteName string, description string, serviceCert string) (*CloudSiteConfig, error) {
+func (c *Client) setCloudSiteName(ctx context.Context, siteName string, description string, serviceCert string) (*CloudSiteConfig, error) {
 	reqURL, err := c.buildRequestURL("/config/site")
 
 	if err != nil {
@@ -325,12 +479,17 @@ func (c *Client) setCloudSiteName(siteName string, description string, serviceCe
 		return nil, fmt.Errorf("could not marshal request data: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
-	req.Header.Set(ConfigSecretHeader, c.LocalPassword)
+	configSecret, err := c.localPasswordFor(c.VcdaIP)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
+	req.Header.Set(ConfigSecretHeader, configSecret)
 
 	body, err := c.doRequest(req, serviceCert)
 	if err != nil {
@@ -347,7 +506,7 @@ func (c *Client) setCloudSiteName(siteName string, description string, serviceCe
 	return &vcdaSite, nil
 }
 
-func (c *Client) setPublicEndpoint(address string, port int, serviceCert string) error {
+func (c *Client) setPublicEndpoint(ctx context.Context, address string, port int, serviceCert string) error {
 	reqURL, err := c.buildRequestURL("/config/endpoints")
 
 	if err != nil {
@@ -362,7 +521,7 @@ func (c *Client) setPublicEndpoint(address string, port int, serviceCert string)
 		return fmt.Errorf("could not marshal request data: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 
 	if err != nil {
 		return fmt.Errorf("error creating new request: %s", err)
@@ -382,14 +541,14 @@ func (c *Client) setPublicEndpoint(address string, port int, serviceCert string)
 	return nil
 }
 
-func (c *Client) getEndpoints(serviceCert string) (*Endpoints, error) {
+func (c *Client) getEndpoints(ctx context.Context, serviceCert string) (*Endpoints, error) {
 	reqURL, err := c.buildRequestURL("/config/endpoints")
 
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodGet, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *reqURL, nil)
 
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
@@ -410,7 +569,7 @@ func (c *Client) getEndpoints(serviceCert string) (*Endpoints, error) {
 	return &endpoints, nil
 }
 
-func (c *Client) setLookupService(lsURL string, lsThumbprint string, serviceCert string) error {
+func (c *Client) setLookupService(ctx context.Context, lsURL string, lsThumbprint string, serviceCert string) error {
 	reqURL, err := c.buildRequestURL("config/lookup-service")
 
 	if err != nil {
@@ -424,7 +583,7 @@ func (c *Client) setLookupService(lsURL string, lsThumbprint string, serviceCert
 		return fmt.Errorf("could not marshal request data: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 	if err != nil {
 		return fmt.Errorf("error creating new request: %s", err)
 	}
@@ -444,7 +603,7 @@ func (c *Client) setLookupService(lsURL string, lsThumbprint string, serviceCert
 	return nil
 }
 
-func (c *Client) setManagerLookupService(lsURL string, lsThumbprint string, ssoUser string, ssoPassword string, serviceCert string) error {
+func (c *Client) setManagerLookupService(ctx context.Context, lsURL string, lsThumbprint string, ssoUser string, ssoPassword string, serviceCert string) error {
 	reqURL, err := c.buildRequestURL("config/lookup-service")
 
 	if err != nil {
@@ -459,7 +618,7 @@ func (c *Client) setManagerLookupService(lsURL string, lsThumbprint string, ssoU
 		return fmt.Errorf("could not marshal request data: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 	if err != nil {
 		return fmt.Errorf("error creating new request: %s", err)
 	}
@@ -479,7 +638,7 @@ func (c *Client) setManagerLookupService(lsURL string, lsThumbprint string, ssoU
 	return nil
 }
 
-func (c *Client) setReplicatorLookupService(host string, lsURL string, lsThumbprint string, apiURL string, apiThumbprint string, rootPassword string, serviceCert string) (*LookupService, error) {
+func (c *Client) setReplicatorLookupService(ctx context.Context, host string, lsURL string, lsThumbprint string, apiURL string, apiThumbprint string, rootPassword string, serviceCert string) (*LookupService, error) {
 	reqURL, err := c.BuildRequestURL(host, "/config/replicators/lookup-service")
 
 	if err != nil {
@@ -493,7 +652,7 @@ func (c *Client) setReplicatorLookupService(host string, lsURL string, lsThumbpr
 		return nil, fmt.Errorf("could not marshal request data: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
@@ -513,7 +672,93 @@ func (c *Client) setReplicatorLookupService(host string, lsURL string, lsThumbpr
 	return &lookupService, nil
 }
 
-func (c *Client) setVcloud(vcdUsername string, vcdPassword string, vcdURL string, vcdThumbprint string, serviceCert string) error {
+// setReplicatorDataAddress sets the address and port on which the replicator
+// receives the replication (LWD) traffic.
+func (c *Client) setReplicatorDataAddress(ctx context.Context, host string, address string, port int, apiURL string, apiThumbprint string, rootPassword string, serviceCert string) error {
+	reqURL, err := c.BuildRequestURL(host, "/config/replicators/data-address")
+
+	if err != nil {
+		return err
+	}
+
+	reqData := ReplicatorDataAddressData{APIURL: apiURL, APIThumbprint: apiThumbprint, RootPassword: rootPassword, Address: address, Port: port}
+
+	rb, err := json.Marshal(reqData)
+	if err != nil {
+		return fmt.Errorf("could not marshal request data: %s", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
+	if err != nil {
+		return fmt.Errorf("error creating new request: %s", err)
+	}
+
+	_, err = c.DoRequest(host, req, serviceCert)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Client) setTrafficSettings(ctx context.Context, settings TrafficSettings, serviceCert string) (*TrafficSettings, error) {
+	reqURL, err := c.buildRequestURL("/config/traffic-settings")
+
+	if err != nil {
+		return nil, err
+	}
+
+	rb, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal request data: %s", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, *reqURL, strings.NewReader(string(rb)))
+	if err != nil {
+		return nil, fmt.Errorf("error creating new request: %s", err)
+	}
+
+	body, err := c.doRequest(req, serviceCert)
+	if err != nil {
+		return nil, err
+	}
+
+	trafficSettings := TrafficSettings{}
+	err = json.Unmarshal(body, &trafficSettings)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal response body: %s", err)
+	}
+
+	return &trafficSettings, nil
+}
+
+func (c *Client) getTrafficSettings(ctx context.Context, serviceCert string) (*TrafficSettings, error) {
+	reqURL, err := c.buildRequestURL("/config/traffic-settings")
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating new request: %s", err)
+	}
+
+	body, err := c.doRequest(req, serviceCert)
+	if err != nil {
+		return nil, err
+	}
+
+	trafficSettings := TrafficSettings{}
+	err = json.Unmarshal(body, &trafficSettings)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal response body: %s", err)
+	}
+
+	return &trafficSettings, nil
+}
+
+func (c *Client) setVcloud(ctx context.Context, vcdUsername string, vcdPassword string, vcdURL string, vcdThumbprint string, serviceCert string) error {
 	reqData := VcloudConfigData{VcdPassword: vcdPassword, VcdThumbprint: vcdThumbprint, VcdURL: vcdURL + "/api", VcdUsername: vcdUsername}
 
 	rb, err := json.Marshal(reqData)
@@ -527,7 +772,7 @@ func (c *Client) setVcloud(vcdUsername string, vcdPassword string, vcdURL string
 		return err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 	if err != nil {
 		return fmt.Errorf("error creating new request: %s", err)
 	}
@@ -551,21 +796,21 @@ func (c *Client) setVcloud(vcdUsername string, vcdPassword string, vcdURL string
 	return nil
 }
 
-func (c *Client) setTunnel(tunnelURL string, tunnelCertificate string, tunnelRootPassword string, serviceCert string) (*TunnelConfig, error) {
+func (c *Client) setTunnel(ctx context.Context, tunnelURL string, tunnelCertificate string, tunnelRootPassword string, priority *int, serviceCert string) (*TunnelConfig, error) {
 	reqURL, err := c.buildRequestURL("/config/tunnels")
 
 	if err != nil {
 		return nil, err
 	}
 
-	reqData := TunnelData{Certificate: tunnelCertificate, RootPassword: tunnelRootPassword, URL: tunnelURL}
+	reqData := TunnelData{Certificate: tunnelCertificate, RootPassword: tunnelRootPassword, URL: tunnelURL, Priority: priority}
 
 	rb, err := json.Marshal(reqData)
 	if err != nil {
 		return nil, fmt.Errorf("could not marshal request data: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
@@ -585,14 +830,14 @@ func (c *Client) setTunnel(tunnelURL string, tunnelCertificate string, tunnelRoo
 	return &tunnelConfig, nil
 }
 
-func (c *Client) getTunnelConfig(serviceCert string, tunnelID string) (*TunnelConfig, error) {
+func (c *Client) getTunnels(ctx context.Context, serviceCert string) ([]TunnelConfig, error) {
 	reqURL, err := c.buildRequestURL("/config/tunnels")
 
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodGet, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
@@ -609,28 +854,34 @@ func (c *Client) getTunnelConfig(serviceCert string, tunnelID string) (*TunnelCo
 		return nil, fmt.Errorf("could not unmarshal response body: %s", err)
 	}
 
-	var tunnel *TunnelConfig
-	for _, r := range tunnels.Tunnels {
-		if r.ID == tunnelID {
-			tunnel = &r
-			break
-		}
+	return tunnels.Tunnels, nil
+}
+
+// getTunnelConfig returns the tunnel with the given ID, or nil when the
+// manager has no such tunnel.
+func (c *Client) getTunnelConfig(ctx context.Context, serviceCert string, tunnelID string) (*TunnelConfig, error) {
+	tunnels, err := c.getTunnels(ctx, serviceCert)
+	if err != nil {
+		return nil, err
 	}
-	if tunnel == nil {
-		return nil, fmt.Errorf("tunnel with ID: %s was not found", tunnelID)
+
+	for _, tunnel := range tunnels {
+		if tunnel.ID == tunnelID {
+			return &tunnel, nil
+		}
 	}
 
-	return tunnel, nil
+	return nil, nil
 }
 
-func (c *Client) getManagerSiteConfig(serviceCert string) (*SiteConfig, error) {
+func (c *Client) getManagerSiteConfig(ctx context.Context, serviceCert string) (*SiteConfig, error) {
 	reqURL, err := c.buildRequestURL("/config")
 
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodGet, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *reqURL, nil)
 
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
@@ -651,14 +902,14 @@ func (c *Client) getManagerSiteConfig(serviceCert string) (*SiteConfig, error) {
 	return &vcdaSite, nil
 }
 
-func (c *Client) getCloudSiteConfig(serviceCert string) (*CloudSiteConfig, error) {
+func (c *Client) getCloudSiteConfig(ctx context.Context, serviceCert string) (*CloudSiteConfig, error) {
 	reqURL, err := c.buildRequestURL("/config")
 
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodGet, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *reqURL, nil)
 
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
@@ -679,7 +930,7 @@ func (c *Client) getCloudSiteConfig(serviceCert string) (*CloudSiteConfig, error
 	return &vcdaSite, nil
 }
 
-func (c *Client) addReplicator(host string, serviceCert string, description string, owner string, siteName string, details ReplicatorConfigData) (*Replicator, error) {
+func (c *Client) addReplicator(ctx context.Context, host string, serviceCert string, description string, owner string, siteName string, details ReplicatorConfigData) (*Replicator, error) {
 	reqData := ReplicatorData{Description: description, Owner: owner, Site: siteName, ReplicatorID: nil, Details: details}
 
 	rb, err := json.Marshal(reqData)
@@ -692,7 +943,7 @@ func (c *Client) addReplicator(host string, serviceCert string, description stri
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
@@ -712,14 +963,34 @@ func (c *Client) addReplicator(host string, serviceCert string, description stri
 	return &replicator, nil
 }
 
-func (c *Client) getReplicator(host string, serviceCert string, replicatorID string) (*Replicator, error) {
+func (c *Client) getReplicator(ctx context.Context, host string, serviceCert string, replicatorID string) (*Replicator, error) {
+	replicators, err := c.getReplicators(ctx, host, serviceCert)
+	if err != nil {
+		return nil, err
+	}
+
+	var replicator *Replicator
+	for _, r := range replicators {
+		if r.ID == replicatorID {
+			replicator = &r
+			break
+		}
+	}
+	if replicator == nil {
+		return nil, fmt.Errorf("replicator with ID: %s was not found", replicatorID)
+	}
+
+	return replicator, nil
+}
+
+func (c *Client) getReplicators(ctx context.Context, host string, serviceCert string) ([]Replicator, error) {
 	reqURL, err := c.BuildRequestURL(host, "/replicators")
 
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodGet, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
@@ -736,21 +1007,10 @@ func (c *Client) getReplicator(host string, serviceCert string, replicatorID str
 		return nil, fmt.Errorf("could not unmarshal response body: %s", err)
 	}
 
-	var replicator *Replicator
-	for _, r := range replicators {
-		if r.ID == replicatorID {
-			replicator = &r
-			break
-		}
-	}
-	if replicator == nil {
-		return nil, fmt.Errorf("replicator with ID: %s was not found", replicatorID)
-	}
-
-	return replicator, nil
+	return replicators, nil
 }
 
-func (c *Client) repairReplicator(host string, serviceCert string, replicatorID string, apiURL string, apiThumbprint string, rootPassword string, ssoUser string, ssoPassword string) error {
+func (c *Client) repairReplicator(ctx context.Context, host string, serviceCert string, replicatorID string, apiURL string, apiThumbprint string, rootPassword string, ssoUser string, ssoPassword string) error {
 	reqData := ReplicatorConfigData{APIURL: apiURL, APIThumbprint: apiThumbprint, RootPassword: rootPassword, SsoUser: ssoUser, SsoPassword: ssoPassword}
 
 	rb, err := json.Marshal(reqData)
@@ -758,13 +1018,13 @@ func (c *Client) repairReplicator(host string, serviceCert string, replicatorID
 		return fmt.Errorf("could not marshal request data: %s", err)
 	}
 
-	path := "/replicators" + replicatorID + "/reset-cookie"
+	path := "/replicators/" + replicatorID + "/reset-cookie"
 	reqURL, err := c.BuildRequestURL(host, path)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 	if err != nil {
 		return fmt.Errorf("error creating new request: %s", err)
 	}
@@ -784,14 +1044,14 @@ func (c *Client) repairReplicator(host string, serviceCert string, replicatorID
 	return nil
 }
 
-func (c *Client) deleteReplicator(host string, serviceCert string, replicatorID string) error {
+func (c *Client) deleteReplicator(ctx context.Context, host string, serviceCert string, replicatorID string) error {
 	reqURL, err := c.BuildRequestURL(host, "/replicators/"+replicatorID)
 
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest(http.MethodDelete, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, *reqURL, nil)
 	if err != nil {
 		return fmt.Errorf("error creating new request: %s", err)
 	}
@@ -814,14 +1074,112 @@ func (c *Client) deleteReplicator(host string, serviceCert string, replicatorID
 	return nil
 }
 
-func (c *Client) setVspherePlugin(serviceCert string) (*VspherePluginStatus, error) {
+// forceDeleteReplicator removes the replicator from the manager even when the
+// replicator is unreachable.
+func (c *Client) forceDeleteReplicator(ctx context.Context, host string, serviceCert string, replicatorID string) (*Task, error) {
+	reqURL, err := c.BuildRequestURL(host, "/replicators/"+replicatorID+"/force-delete")
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating new request: %s", err)
+	}
+
+	body, err := c.DoRequest(host, req, serviceCert)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalTask(body), nil
+}
+
+// resetReplicatorLookupService removes the Lookup service registration of the
+// replicator behind apiURL.
+func (c *Client) resetReplicatorLookupService(ctx context.Context, host string, apiURL string, apiThumbprint string, rootPassword string, serviceCert string) (*Task, error) {
+	reqURL, err := c.BuildRequestURL(host, "/config/replicators/lookup-service")
+
+	if err != nil {
+		return nil, err
+	}
+
+	reqData := ReplicatorLookupServiceData{APIURL: apiURL, APIThumbprint: apiThumbprint, RootPassword: rootPassword}
+
+	rb, err := json.Marshal(reqData)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal request data: %s", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, *reqURL, strings.NewReader(string(rb)))
+	if err != nil {
+		return nil, fmt.Errorf("error creating new request: %s", err)
+	}
+
+	body, err := c.DoRequest(host, req, serviceCert)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalTask(body), nil
+}
+
+// setReplicatorMaintenanceMode enters or exits the maintenance mode of the
+// replicator. A replicator in maintenance mode takes no new replications.
+func (c *Client) setReplicatorMaintenanceMode(ctx context.Context, host string, serviceCert string, replicatorID string, maintenanceMode bool) error {
+	path := "/replicators/" + replicatorID + "/exit-maintenance-mode"
+	if maintenanceMode {
+		path = "/replicators/" + replicatorID + "/enter-maintenance-mode"
+	}
+
+	reqURL, err := c.BuildRequestURL(host, path)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating new request: %s", err)
+	}
+
+	_, err = c.DoRequest(host, req, serviceCert)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// rebalanceReplicator moves the replications of the replicator to the other
+// replicators of the site.
+func (c *Client) rebalanceReplicator(ctx context.Context, host string, serviceCert string, replicatorID string) error {
+	reqURL, err := c.BuildRequestURL(host, "/replicators/"+replicatorID+"/rebalance")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating new request: %s", err)
+	}
+
+	_, err = c.DoRequest(host, req, serviceCert)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Client) setVspherePlugin(ctx context.Context, serviceCert string) (*VspherePluginStatus, error) {
 	reqURL, err := c.buildRequestURL("config/vsphere-ui")
 
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
@@ -841,14 +1199,14 @@ func (c *Client) setVspherePlugin(serviceCert string) (*VspherePluginStatus, err
 	return &pluginStatus, nil
 }
 
-func (c *Client) removeVspherePlugin(serviceCert string) error {
+func (c *Client) removeVspherePlugin(ctx context.Context, serviceCert string) error {
 	reqURL, err := c.buildRequestURL("config/vsphere-ui")
 
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest(http.MethodDelete, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, *reqURL, nil)
 	if err != nil {
 		return fmt.Errorf("error creating new request: %s", err)
 	}
@@ -861,14 +1219,71 @@ func (c *Client) removeVspherePlugin(serviceCert string) error {
 	return nil
 }
 
-func (c *Client) isConfigured(serviceCert string) (*IsServiceConfigured, error) {
+func (c *Client) deleteTunnel(ctx context.Context, serviceCert string, tunnelID string) (*Task, error) {
+	return c.removeConfig(ctx, serviceCert, "/config/tunnels/"+tunnelID)
+}
+
+// removeConfig removes the configuration at path and returns the task that
+// removes it, if the appliance removes it asynchronously.
+func (c *Client) removeConfig(ctx context.Context, serviceCert string, path string) (*Task, error) {
+	reqURL, err := c.buildRequestURL(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, *reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating new request: %s", err)
+	}
+
+	body, err := c.doRequest(req, serviceCert)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalTask(body), nil
+}
+
+func (c *Client) resetConfig(ctx context.Context, serviceCert string) (*Task, error) {
+	reqURL, err := c.buildRequestURL("/config/reset")
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating new request: %s", err)
+	}
+
+	body, err := c.doRequest(req, serviceCert)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalTask(body), nil
+}
+
+// unmarshalTask returns the task in a response body, or nil when the body is
+// not a task.
+func unmarshalTask(body []byte) *Task {
+	task := Task{}
+	if err := json.Unmarshal(bytes.TrimSpace(body), &task); err != nil || task.ID == "" || task.State == "" {
+		return nil
+	}
+
+	return &task
+}
+
+func (c *Client) isConfigured(ctx context.Context, serviceCert string) (*IsServiceConfigured, error) {
 	reqURL, err := c.buildRequestURL("/config/is-configured")
 
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodGet, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *reqURL, nil)
 
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
@@ -889,19 +1304,24 @@ func (c *Client) isConfigured(serviceCert string) (*IsServiceConfigured, error)
 	return &isServiceConfigured, nil
 }
 
-func (c *Client) getTask(serviceCert string, taskID string) (*Task, error) {
-	reqURL, err := c.buildRequestURL("/tasks/" + taskID)
+func (c *Client) getTask(ctx context.Context, serviceCert string, taskID string) (*Task, error) {
+	return c.getTaskOn(ctx, c.VcdaIP, serviceCert, taskID)
+}
+
+// getTaskOn returns the task with the given ID from the service at host.
+func (c *Client) getTaskOn(ctx context.Context, host string, serviceCert string, taskID string) (*Task, error) {
+	reqURL, err := c.BuildRequestURL(host, "/tasks/"+taskID)
 
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodGet, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
 
-	body, err := c.doRequest(req, serviceCert)
+	body, err := c.DoRequest(host, req, serviceCert)
 	if err != nil {
 		return nil, err
 	}
@@ -916,7 +1336,7 @@ func (c *Client) getTask(serviceCert string, taskID string) (*Task, error) {
 	return &task, nil
 }
 
-func (c *Client) pairSite(serviceCert string, apiThumbprint string, apiURL string, description string, site string) (*string, error) {
+func (c *Client) pairSite(ctx context.Context, serviceCert string, apiThumbprint string, apiURL string, description string, site string) (*string, error) {
 	reqURL, err := c.buildRequestURL("/sites")
 	if err != nil {
 		return nil, err
@@ -934,7 +1354,7 @@ func (c *Client) pairSite(serviceCert string, apiThumbprint string, apiURL strin
 		return nil, fmt.Errorf("could not marshal request data: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, strings.NewReader(string(rb)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, strings.NewReader(string(rb)))
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
@@ -958,7 +1378,7 @@ func (c *Client) pairSite(serviceCert string, apiThumbprint string, apiURL strin
 	return &taskID, nil
 }
 
-func (c *Client) repairSite(serviceCert string, site string, apiThumbprint string, apiURL string, description string) (*string, error) {
+func (c *Client) repairSite(ctx context.Context, serviceCert string, site string, apiThumbprint string, apiURL string, description string) (*string, error) {
 	reqURL, err := c.buildRequestURL("/sites/" + site)
 	if err != nil {
 		return nil, err
@@ -971,7 +1391,7 @@ func (c *Client) repairSite(serviceCert string, site string, apiThumbprint strin
 		return nil, fmt.Errorf("could not marshal request data: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPut, *reqURL, strings.NewReader(string(rb)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, *reqURL, strings.NewReader(string(rb)))
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
@@ -995,13 +1415,13 @@ func (c *Client) repairSite(serviceCert string, site string, apiThumbprint strin
 	return &taskID, nil
 }
 
-func (c *Client) unpairSite(serviceCert string, site string) (*string, error) {
+func (c *Client) unpairSite(ctx context.Context, serviceCert string, site string) (*string, error) {
 	reqURL, err := c.buildRequestURL("/sites/" + site)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodDelete, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, *reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
@@ -1025,14 +1445,14 @@ func (c *Client) unpairSite(serviceCert string, site string) (*string, error) {
 	return &taskID, nil
 }
 
-func (c *Client) getVcenterSite(serviceCert string, apiURL string) (*VcenterSite, error) {
+func (c *Client) getVcenterSite(ctx context.Context, serviceCert string, apiURL string) (*VcenterSite, error) {
 	reqURL, err := c.buildRequestURL("/sites")
 
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodGet, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
@@ -1064,14 +1484,14 @@ func (c *Client) getVcenterSite(serviceCert string, apiURL string) (*VcenterSite
 	return vcdaSite, nil
 }
 
-func (c *Client) getCloudSite(serviceCert string, apiURL string) (*CloudSite, error) {
+func (c *Client) getCloudSite(ctx context.Context, serviceCert string, apiURL string) (*CloudSite, error) {
 	reqURL, err := c.buildRequestURL("/sites")
 
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodGet, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
@@ -1103,13 +1523,13 @@ func (c *Client) getCloudSite(serviceCert string, apiURL string) (*CloudSite, er
 	return vcdaSite, nil
 }
 
-func (c *Client) getCloudHealth(serviceCert string) (*string, error) {
+func (c *Client) getCloudHealth(ctx context.Context, serviceCert string) (*string, error) {
 	reqURL, err := c.buildRequestURL("diagnostics/health")
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, *reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating new request: %s", err)
 	}
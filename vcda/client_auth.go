@@ -0,0 +1,163 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+)
+
+// The authentication types of the provider and the appliance settings.
+const (
+	AuthTypeLocal = "local"
+	AuthTypeSSO   = "sso"
+	AuthTypeVCD   = "vcd"
+)
+
+// authTypes lists the supported authentication types.
+var authTypes = []string{AuthTypeLocal, AuthTypeSSO, AuthTypeVCD}
+
+// AuthConfig holds the credentials used to open an appliance session. User
+// and Password are not used by the local authentication type, which always
+// authenticates the local user of the provider. PasswordCommand, when set,
+// prints the password instead.
+type AuthConfig struct {
+	Type            string
+	User            string
+	Password        string
+	PasswordCommand string
+}
+
+// credentialStore holds the root passwords changed during a Terraform run,
+// keyed by appliance address. It is shared by resources applied in parallel.
+type credentialStore struct {
+	mu        sync.RWMutex
+	passwords map[string]string
+}
+
+func (cs *credentialStore) get(address string) (string, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	password, ok := cs.passwords[address]
+	return password, ok
+}
+
+func (cs *credentialStore) set(address string, password string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.passwords == nil {
+		cs.passwords = make(map[string]string)
+	}
+	cs.passwords[address] = password
+}
+
+// applianceAddress returns host without the port.
+func applianceAddress(host string) string {
+	address, _, err := net.SplitHostPort(host)
+	if err != nil {
+		return host
+	}
+
+	return address
+}
+
+// localPasswordFor returns the current root password of the appliance at
+// host: the password changed during this run, if any, or else the output of
+// the appliance local_password_command, or else the provider local_password.
+func (c *Client) localPasswordFor(host string) (string, error) {
+	if password, ok := c.credentials.get(applianceAddress(host)); ok {
+		return password, nil
+	}
+
+	return c.commandOrSecret(c.applianceFor(host).LocalPasswordCommand, c.LocalPassword)
+}
+
+// setLocalPassword records the new root password of the appliance at host.
+func (c *Client) setLocalPassword(host string, password string) {
+	c.credentials.set(applianceAddress(host), password)
+}
+
+// rootPasswordFor returns the root password of the appliance behind
+// applianceURL: the password changed during this run, if any, or else
+// configured.
+func (c *Client) rootPasswordFor(applianceURL string, configured string) string {
+	u, err := url.Parse(applianceURL)
+	if err != nil || u.Hostname() == "" {
+		return configured
+	}
+
+	if password, ok := c.credentials.get(u.Hostname()); ok {
+		return password
+	}
+
+	return configured
+}
+
+// applianceFor returns the settings of the appliance reachable at host, or
+// empty settings when there is no matching appliance block.
+func (c *Client) applianceFor(host string) ApplianceConfig {
+	return c.Appliances[applianceAddress(host)]
+}
+
+// authFor returns the authentication settings for host. The settings of a
+// matching appliance block take precedence over the provider settings.
+func (c *Client) authFor(host string) AuthConfig {
+	auth := c.Auth
+	appliance := c.applianceFor(host).Auth
+
+	if appliance.Type != "" {
+		auth.Type = appliance.Type
+	}
+	if appliance.User != "" {
+		auth.User = appliance.User
+	}
+	if appliance.Password != "" {
+		auth.Password = appliance.Password
+		auth.PasswordCommand = ""
+	}
+	if appliance.PasswordCommand != "" {
+		auth.Password = ""
+		auth.PasswordCommand = appliance.PasswordCommand
+	}
+	if auth.Type == "" {
+		auth.Type = AuthTypeLocal
+	}
+
+	return auth
+}
+
+// authTokenData returns the session request for host. The local password is
+// used only when host is authenticated with the local user.
+func (c *Client) authTokenData(host string, localPassword string) (*AuthTokenData, error) {
+	auth := c.authFor(host)
+
+	if auth.Type != AuthTypeLocal {
+		password, err := c.commandOrSecret(auth.PasswordCommand, auth.Password)
+		if err != nil {
+			return nil, err
+		}
+		auth.Password = password
+	}
+
+	switch auth.Type {
+	case AuthTypeLocal:
+		return &AuthTokenData{Type: UserType, LocalUser: c.LocalUser, LocalPassword: localPassword}, nil
+	case AuthTypeSSO:
+		if auth.User == "" || auth.Password == "" {
+			return nil, fmt.Errorf("auth_user and auth_password are required for the %s authentication of appliance %s", auth.Type, host)
+		}
+		return &AuthTokenData{Type: SsoUserType, Username: auth.User, Password: auth.Password}, nil
+	case AuthTypeVCD:
+		if auth.User == "" || auth.Password == "" {
+			return nil, fmt.Errorf("auth_user and auth_password are required for the %s authentication of appliance %s", auth.Type, host)
+		}
+		return &AuthTokenData{Type: VcdUserType, VcdUser: auth.User, VcdPassword: auth.Password}, nil
+	default:
+		return nil, fmt.Errorf("unsupported authentication type %q for appliance %s", auth.Type, host)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// credentialCommandTimeout bounds the run time of a credential command.
+const credentialCommandTimeout = time.Minute
+
+// credentialCommands runs the credential commands, which print a secret to
+// stdout, and caches their output for the duration of a Terraform run, so
+// that every command runs at most once.
+type credentialCommands struct {
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+// secret returns the secret printed by command.
+func (cc *credentialCommands) secret(command string) (string, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if secret, ok := cc.secrets[command]; ok {
+		return secret, nil
+	}
+
+	secret, err := runCredentialCommand(command)
+	if err != nil {
+		return "", err
+	}
+
+	if cc.secrets == nil {
+		cc.secrets = make(map[string]string)
+	}
+	cc.secrets[command] = secret
+
+	return secret, nil
+}
+
+// runCredentialCommand runs command with the system shell and returns its
+// output without the surrounding white space.
+func runCredentialCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("credential command %q did not finish within %s", command, credentialCommandTimeout)
+		}
+		return "", fmt.Errorf("credential command %q failed: %s: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	secret := strings.TrimSpace(stdout.String())
+	if secret == "" {
+		return "", fmt.Errorf("credential command %q printed an empty secret", command)
+	}
+
+	return secret, nil
+}
+
+// commandOrSecret returns the output of command when set, otherwise secret.
+func (c *Client) commandOrSecret(command string, secret string) (string, error) {
+	if command == "" {
+		return secret, nil
+	}
+
+	return c.commands.secret(command)
+}
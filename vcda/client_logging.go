@@ -0,0 +1,171 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redactedValue replaces the secrets in the logged requests and responses.
+const redactedValue = "***"
+
+// redactedHeaders lists the headers whose values are never logged.
+var redactedHeaders = []string{VcdaAuthTokenHeader, ConfigSecretHeader}
+
+// redactedFields lists the JSON fields whose values are never logged, at any
+// depth of a request or response body. The license key is sent as "key".
+var redactedFields = map[string]bool{
+	"rootPassword":  true,
+	"vcdPassword":   true,
+	"ssoPassword":   true,
+	"localPassword": true,
+	"password":      true,
+	"key":           true,
+}
+
+// loggingTransport logs the VCDA API requests and responses with tflog: the
+// method, URL, status, duration and task ID at DEBUG, and the headers and
+// bodies at TRACE, with the secrets redacted. The logs are written to the
+// Terraform log when the request context comes from the Terraform SDK.
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	fields := map[string]interface{}{
+		"method": req.Method,
+		"url":    req.URL.String(),
+	}
+
+	traceFields := map[string]interface{}{
+		"headers": redactHeaders(req.Header),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			traceFields["body"] = redactBody(data)
+		}
+	}
+	tflog.Trace(ctx, "Sending VCDA API request", mergeFields(fields, traceFields))
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	fields["duration_ms"] = time.Since(start).Milliseconds()
+
+	if err != nil {
+		fields["error"] = err.Error()
+		tflog.Debug(ctx, "VCDA API request failed", fields)
+		return nil, err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	fields["status"] = resp.StatusCode
+	if taskID := responseTaskID(data); taskID != "" {
+		fields["task_id"] = taskID
+		trace.SpanFromContext(ctx).SetAttributes(attrTaskID.String(taskID))
+	}
+	tflog.Debug(ctx, "VCDA API request", fields)
+
+	tflog.Trace(ctx, "Received VCDA API response", mergeFields(fields, map[string]interface{}{
+		"headers": redactHeaders(resp.Header),
+		"body":    redactBody(data),
+	}))
+
+	return resp, nil
+}
+
+func mergeFields(fields map[string]interface{}, extra map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(fields)+len(extra))
+	for k, v := range fields {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// redactHeaders returns the headers with the values of redactedHeaders
+// replaced.
+func redactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name := range header {
+		headers[name] = header.Get(name)
+	}
+	for _, name := range redactedHeaders {
+		if header.Get(name) != "" {
+			headers[http.CanonicalHeaderKey(name)] = redactedValue
+		}
+	}
+
+	return headers
+}
+
+// redactBody returns the JSON body with the values of redactedFields
+// replaced. A body that is not JSON is returned as it is, since the API
+// sends secrets in JSON bodies only.
+func redactBody(data []byte) string {
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return string(data)
+	}
+
+	redacted, err := json.Marshal(redactValue(body))
+	if err != nil {
+		return redactedValue
+	}
+
+	return string(redacted)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if redactedFields[k] {
+				v[k] = redactedValue
+				continue
+			}
+			v[k] = redactValue(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactValue(value)
+		}
+	}
+
+	return v
+}
+
+// responseTaskID returns the ID of the task in a task response body, or an
+// empty string for other responses.
+func responseTaskID(data []byte) string {
+	var task struct {
+		ID    string `json:"id"`
+		State string `json:"state"`
+	}
+	if err := json.Unmarshal(data, &task); err != nil || task.State == "" {
+		return ""
+	}
+
+	return task.ID
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// ApplianceConfig holds the provider settings of a single appliance, matched
+// by the appliance address.
+type ApplianceConfig struct {
+	Address      string
+	VMName       string
+	DatacenterID string
+	Thumbprint   string
+	Auth         AuthConfig
+	// LocalPasswordCommand prints the local password of the appliance.
+	LocalPasswordCommand string
+}
+
+// servicePortRoles maps the ports of the services that an appliance runs
+// besides its main service on port 443 to the roles whose certificates the
+// services present.
+var servicePortRoles = map[string]string{
+	"8441": "manager",
+	"8043": "replicator",
+	"8047": "tunnel",
+	"8048": "tunnel",
+}
+
+// serviceCertCache caches the discovered service certificates per service
+// address and role for the duration of a Terraform run.
+type serviceCertCache struct {
+	mu    sync.Mutex
+	certs map[string]string
+}
+
+func (sc *serviceCertCache) get(key string) (string, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	cert, ok := sc.certs[key]
+	return cert, ok
+}
+
+func (sc *serviceCertCache) set(key string, cert string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.certs == nil {
+		sc.certs = make(map[string]string)
+	}
+	sc.certs[key] = cert
+}
+
+// serviceCertFor returns serviceCert when set, otherwise the discovered
+// service certificate of the service reachable at host. The port of host
+// selects the role of the service, see servicePortRoles.
+func (c *Client) serviceCertFor(ctx context.Context, host string, serviceCert string) (string, error) {
+	if serviceCert != "" {
+		return serviceCert, nil
+	}
+
+	address, port, err := net.SplitHostPort(host)
+	if err != nil {
+		address, port = host, "443"
+	}
+	role := servicePortRoles[port]
+	key := net.JoinHostPort(address, port) + "/" + role
+
+	if cert, ok := c.serviceCerts.get(key); ok {
+		return cert, nil
+	}
+
+	appliance := c.applianceFor(host)
+
+	cert, err := c.discoverServiceCert(ctx, address, role, appliance)
+	if err != nil && appliance.Thumbprint != "" {
+		log.Printf("[DEBUG] Falling back to a pinned certificate fetch for appliance %s: %s", address, err)
+		cert, err = fetchPinnedServiceCert(ctx, c.APITimeout, address, port, appliance.Thumbprint)
+	}
+	if err != nil {
+		return "", fmt.Errorf("service_cert is not set and the certificate of appliance %s could not be discovered: %s", address, err)
+	}
+
+	c.serviceCerts.set(key, cert)
+
+	return cert, nil
+}
+
+// discoverServiceCert reads the service certificate of the given role from
+// the extraConfig of the appliance VM, found by its configured VM name or else
+// by its IP address. Without a role, the certificate of the main service of
+// the appliance is read, which is the first role found in the order of
+// applianceRoles.
+func (c *Client) discoverServiceCert(ctx context.Context, address string, role string, appliance ApplianceConfig) (string, error) {
+	vimClient, err := c.vSphere()
+	if err != nil {
+		return "", err
+	}
+
+	var dc *object.Datacenter
+	if appliance.DatacenterID != "" {
+		dc, err = datacenterFromID(ctx, vimClient, appliance.DatacenterID)
+		if err != nil {
+			return "", fmt.Errorf("cannot locate datacenter: %s", err)
+		}
+	}
+
+	var vm *object.VirtualMachine
+	if appliance.VMName != "" {
+		log.Printf("[DEBUG] Looking for appliance VM by name/path %q", appliance.VMName)
+		vm, err = FromPath(ctx, vimClient, appliance.VMName, dc)
+	} else {
+		log.Printf("[DEBUG] Looking for appliance VM by IP address %q", address)
+		vm, err = FromIP(ctx, vimClient, address, dc)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error fetching virtual machine: %s", err)
+	}
+
+	props, err := Properties(ctx, vimClient, vm)
+	if err != nil {
+		return "", fmt.Errorf("error fetching virtual machine properties: %s", err)
+	}
+
+	if key := serviceCertExtraConfigKey(props, role); key != "" {
+		log.Printf("[DEBUG] Discovered service certificate of appliance %s from %s", address, key)
+		return extraConfigValue(props, key), nil
+	}
+
+	if role != "" {
+		return "", fmt.Errorf("no %s certificate was found in the extraConfig of virtual machine %q", role, vm.InventoryPath)
+	}
+	return "", fmt.Errorf("no appliance certificate was found in the extraConfig of virtual machine %q", vm.InventoryPath)
+}
+
+// serviceCertExtraConfigKey returns the extraConfig key of the appliance VM
+// that holds the certificate of the given role, or of the first role found
+// when role is empty. It returns an empty key when the VM has no such
+// certificate.
+func serviceCertExtraConfigKey(props *mo.VirtualMachine, role string) string {
+	for _, r := range applianceRoles {
+		if role != "" && r.Role != role {
+			continue
+		}
+		if extraConfigValue(props, r.ExtraConfigKey) != "" {
+			return r.ExtraConfigKey
+		}
+	}
+
+	return ""
+}
+
+// fetchPinnedServiceCert fetches the certificate presented at address:port
+// and returns it in the base64-encoded DER format of service_cert, provided
+// that its SHA-256 thumbprint matches the pinned thumbprint.
+func fetchPinnedServiceCert(ctx context.Context, timeout time.Duration, address string, port string, thumbprint string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	chain, err := fetchPeerCertificates(ctx, address, port, "")
+	if err != nil {
+		return "", err
+	}
+
+	actual := formatFingerprint(sha256.Sum256(chain[0].Raw))
+	if !thumbprintsEqual(thumbprint, actual) {
+		return "", fmt.Errorf("thumbprint mismatch: expected %s, but the appliance presented %s", thumbprint, actual)
+	}
+
+	return base64.StdEncoding.EncodeToString(chain[0].Raw), nil
+}
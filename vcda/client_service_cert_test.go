@@ -0,0 +1,38 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// TestServiceCert_roleForPort discovers the certificates of the services of a
+// Cloud Director Replication Management Appliance, which runs several
+// services with different certificates.
+func (at *AccTests) TestServiceCert_roleForPort(t *testing.T) {
+	props := &mo.VirtualMachine{Config: &types.VirtualMachineConfigInfo{
+		ExtraConfig: []types.BaseOptionValue{
+			&types.OptionValue{Key: ManagerCertExtraConfigKey, Value: "manager-cert"},
+			&types.OptionValue{Key: CloudCertExtraConfigKey, Value: "cloud-cert"},
+		},
+	}}
+
+	for _, tc := range []struct {
+		port string
+		want string
+	}{
+		{"443", CloudCertExtraConfigKey},
+		{"8441", ManagerCertExtraConfigKey},
+		{"8047", ""},
+	} {
+		if got := serviceCertExtraConfigKey(props, servicePortRoles[tc.port]); got != tc.want {
+			t.Errorf("expected the certificate of port %s to be read from %q, got %q", tc.port, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// errCertificatePinMismatch is returned by the pinned certificate verifier
+// when the peer presents a certificate other than the pinned one.
+var errCertificatePinMismatch = errors.New("presented certificate does not match the pinned certificate")
+
+// loadCABundle builds a certificate pool from an inline PEM bundle and/or a
+// PEM bundle file. It returns nil when neither is given.
+func loadCABundle(bundle string, bundleFile string) (*x509.CertPool, error) {
+	data := []byte(bundle)
+
+	if bundleFile != "" {
+		fileData, err := os.ReadFile(filepath.Clean(bundleFile))
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA bundle file: %s", err)
+		}
+		data = append(data, '\n')
+		data = append(data, fileData...)
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA bundle does not contain any PEM encoded certificates")
+	}
+
+	return pool, nil
+}
+
+// pinnedTLSConfig returns a TLS configuration that accepts the connection
+// only when the peer's end entity certificate has the given SHA-256
+// fingerprint. The certificate chain and the host name are not verified,
+// which makes it suitable for appliances addressed by an IP address that is
+// not part of their certificate.
+func pinnedTLSConfig(fingerprint [32]byte) *tls.Config {
+	return &tls.Config{
+		// the peer certificate is verified by VerifyPeerCertificate below
+		InsecureSkipVerify: true, // #nosec G402
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errCertificatePinMismatch
+			}
+			if sha256.Sum256(rawCerts[0]) != fingerprint {
+				return fmt.Errorf("%w: got %s, expected %s", errCertificatePinMismatch,
+					formatFingerprint(sha256.Sum256(rawCerts[0])), formatFingerprint(fingerprint))
+			}
+			return nil
+		},
+	}
+}
+
+// isCertificateError reports whether err was caused by a failed certificate
+// verification.
+func isCertificateError(err error) bool {
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var verificationErr *tls.CertificateVerificationError
+
+	return errors.As(err, &unknownAuthorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr) ||
+		errors.As(err, &verificationErr) ||
+		errors.Is(err, errCertificatePinMismatch)
+}
@@ -8,15 +8,17 @@ package vcda
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 	"time"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/session"
 	"github.com/vmware/govmomi/vim25"
@@ -37,6 +39,45 @@ type VimClient struct {
 	timeout time.Duration
 }
 
+// vimConnection holds the vSphere connection of a Client once established.
+type vimConnection struct {
+	mu     sync.Mutex
+	client *VimClient
+}
+
+// vSphere returns the vSphere connection, logging in on first use. It fails
+// when the provider is configured without vSphere, so that only the features
+// that need vSphere require it.
+func (c *Client) vSphere() (*VimClient, error) {
+	if c.vimConfig == nil {
+		return nil, fmt.Errorf("this operation requires a vSphere connection, " +
+			"set vsphere_server, vsphere_user and vsphere_password in the provider configuration")
+	}
+
+	c.vim.mu.Lock()
+	defer c.vim.mu.Unlock()
+
+	if c.vim.client != nil {
+		return c.vim.client, nil
+	}
+
+	// the password command runs only when vSphere is first used, so that
+	// a run that does not need vSphere does not need its password
+	password, err := c.commandOrSecret(c.vimConfig.PasswordCommand, c.vimConfig.Password)
+	if err != nil {
+		return nil, err
+	}
+	c.vimConfig.Password = password
+
+	client, err := c.vimConfig.VimClient()
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize vim client: %s", err)
+	}
+	c.vim.client = client
+
+	return client, nil
+}
+
 // Config holds the provider configuration, and delivers a populated
 // VSphereClient based off the contained settings.
 type Config struct {
@@ -51,35 +92,69 @@ type Config struct {
 	VimSessionPath string
 	KeepAlive      int
 	APITimeout     time.Duration
+
+	// PasswordCommand, when set, prints the password that is used instead
+	// of Password. It runs when vSphere is first used, see Client.vSphere.
+	PasswordCommand string
+
+	// RootCAs, when set, is used to verify the vSphere server certificate
+	// instead of honoring InsecureFlag.
+	RootCAs *x509.CertPool
 }
 
-// NewConfig returns a new Config from a supplied ResourceData.
-func NewConfig(d *schema.ResourceData) (*Config, error) {
-	// Handle backcompat support for vcenter_server; once that is removed,
-	// vsphere_server can just become a Required field that is referenced inline
-	// in Config below.
-	server := d.Get("vsphere_server").(string)
+// NewConfig returns a new Config from the resolved provider settings.
+func NewConfig(s *providerSettings) (*Config, error) {
+	insecure, err := s.Bool("vsphere_allow_unverified_ssl", VsphereAllowUnverifiedSSL)
+	if err != nil {
+		return nil, err
+	}
+
+	debug, err := s.Bool("client_debug", VsphereClientDebug)
+	if err != nil {
+		return nil, err
+	}
+
+	persist, err := s.Bool("persist_session", VspherePersistSession)
+	if err != nil {
+		return nil, err
+	}
+
+	keepAlive, err := s.Int("vim_keep_alive", VsphereVimKeepAlive)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := s.Duration("api_timeout", defaultAPITimeout)
+	if err != nil {
+		return nil, err
+	}
 
-	if server == "" {
-		server = d.Get("vcenter_server").(string)
+	debugPath, err := expandHome(s.String("client_debug_path"))
+	if err != nil {
+		return nil, err
 	}
 
-	if server == "" {
-		return nil, fmt.Errorf("one of vsphere_server or [deprecated] vcenter_server must be provided")
+	sessionPath := s.String("vim_session_path")
+	if sessionPath == "" {
+		sessionPath = defaultVimSessionPath
+	}
+	sessionPath, err = expandHome(sessionPath)
+	if err != nil {
+		return nil, err
 	}
 
 	c := &Config{
-		User:           d.Get("vsphere_user").(string),
-		Password:       d.Get("vsphere_password").(string),
-		InsecureFlag:   d.Get("vsphere_allow_unverified_ssl").(bool),
-		VSphereServer:  server,
-		Debug:          false,
+		User:           s.String("vsphere_user"),
+		Password:       s.String("vsphere_password"),
+		InsecureFlag:   insecure,
+		VSphereServer:  s.String("vsphere_server"),
+		Debug:          debug,
 		DebugPathRun:   "",
-		DebugPath:      "",
-		Persist:        false,
-		VimSessionPath: "",
-		KeepAlive:      10,
-		APITimeout:     defaultAPITimeout,
+		DebugPath:      debugPath,
+		Persist:        persist,
+		VimSessionPath: sessionPath,
+		KeepAlive:      keepAlive,
+		APITimeout:     timeout,
 	}
 
 	return c, nil
@@ -119,9 +194,6 @@ func (c *Config) VimClient() (*VimClient, error) {
 
 	log.Printf("[DEBUG] VMWare vSphere Client configured for URL: %s", c.VSphereServer)
 
-	_, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
-	defer cancel()
-
 	// Done, save sessions if we need to and return
 	if err := c.SaveVimClient(client.vimClient); err != nil {
 		return nil, fmt.Errorf("error persisting SOAP session to disk: %s", err)
@@ -229,11 +301,18 @@ func (c *Config) SaveVimClient(client *govmomi.Client) error {
 	if err != nil {
 		return err
 	}
+	if err := checkSessionFileMode(filepath.Dir(p)); err != nil {
+		return err
+	}
 
-	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY, 0600)
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
+	if err := checkSessionFileMode(p); err != nil {
+		_ = f.Close()
+		return err
+	}
 
 	defer func() {
 		if err = f.Close(); err != nil {
@@ -270,6 +349,10 @@ func (c *Config) restoreVimClient(client *vim25.Client) (bool, error) {
 
 		return false, err
 	}
+	if err := checkSessionFileMode(p); err != nil {
+		_ = f.Close()
+		return false, err
+	}
 
 	defer func() {
 		if err = f.Close(); err != nil {
@@ -286,6 +369,28 @@ func (c *Config) restoreVimClient(client *vim25.Client) (bool, error) {
 	return true, nil
 }
 
+// checkSessionFileMode returns an error when the session file or directory
+// at name can be read or written by other users, since a persisted session
+// grants access to vSphere as the provider user. The check is skipped on
+// Windows, whose file modes do not reflect the access control lists.
+func checkSessionFileMode(name string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("the vSphere session path %q is accessible by other users (mode %s), "+
+			"restrict its permissions to the owner with chmod go-rwx", name, info.Mode().Perm())
+	}
+
+	return nil
+}
+
 // LoadVimClient loads a saved vSphere SOAP API session from disk, previously
 // saved by SaveVimClient, checking it for validity before returning it. A nil
 // client means that the session is no longer valid and should be created from
@@ -306,8 +411,20 @@ func (c *Config) LoadVimClient() (*govmomi.Client, error) {
 		return nil, nil
 	}
 
+	// decoding the client creates a new SOAP client, which does not have the
+	// CA bundle and keep alive settings that a new session is created with
+	if c.RootCAs != nil {
+		transport := client.Client.DefaultTransport()
+		transport.TLSClientConfig.RootCAs = c.RootCAs
+		transport.TLSClientConfig.InsecureSkipVerify = false
+	}
+	client.RoundTripper = session.KeepAlive(client.RoundTripper, time.Duration(c.KeepAlive)*time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.APITimeout)
+	defer cancel()
+
 	m := session.NewManager(client)
-	u, err := m.UserSession(context.TODO())
+	u, err := m.UserSession(ctx)
 	if err != nil {
 		if soap.IsSoapFault(err) {
 			fault := soap.ToSoapFault(err).VimFault()
@@ -318,7 +435,8 @@ func (c *Config) LoadVimClient() (*govmomi.Client, error) {
 			}
 		}
 
-		return nil, err
+		log.Printf("[DEBUG] Cached SOAP client session could not be checked, new session necessary: %s", err)
+		return nil, nil
 	}
 
 	// If the session is nil, the client is not authenticated
@@ -327,6 +445,8 @@ func (c *Config) LoadVimClient() (*govmomi.Client, error) {
 		return nil, nil
 	}
 
+	client.RoundTripper = &tracingSoapRoundTripper{next: client.RoundTripper, server: c.VSphereServer}
+
 	log.Println("[DEBUG] Cached SOAP client session loaded successfully")
 	return &govmomi.Client{
 		Client:         client,
@@ -337,7 +457,7 @@ func (c *Config) LoadVimClient() (*govmomi.Client, error) {
 // SavedVimSessionOrNew either loads a saved SOAP session from disk, or creates
 // a new one.
 func (c *Config) SavedVimSessionOrNew(u *url.URL) (*govmomi.Client, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.APITimeout)
 	defer cancel()
 
 	client, err := c.LoadVimClient()
@@ -346,8 +466,12 @@ func (c *Config) SavedVimSessionOrNew(u *url.URL) (*govmomi.Client, error) {
 	}
 	if client == nil {
 		log.Printf("[DEBUG] Creating new SOAP API session on endpoint %s", c.VSphereServer)
-		client, err = newClientWithKeepAlive(ctx, u, c.InsecureFlag, c.KeepAlive)
+		client, err = newClientWithKeepAlive(ctx, u, c.InsecureFlag, c.RootCAs, c.KeepAlive)
 		if err != nil {
+			if isCertificateError(err) {
+				return nil, fmt.Errorf("could not verify the certificate of vSphere server %s: %s. "+
+					"Set ca_bundle or ca_bundle_file to the CA certificates that issued it", c.VSphereServer, err)
+			}
 			return nil, fmt.Errorf("error setting up new vSphere SOAP client: %s", err)
 		}
 		log.Println("[DEBUG] SOAP API session creation successful")
@@ -355,8 +479,15 @@ func (c *Config) SavedVimSessionOrNew(u *url.URL) (*govmomi.Client, error) {
 	return client, nil
 }
 
-func newClientWithKeepAlive(ctx context.Context, u *url.URL, insecure bool, keepAlive int) (*govmomi.Client, error) {
+func newClientWithKeepAlive(ctx context.Context, u *url.URL, insecure bool, rootCAs *x509.CertPool, keepAlive int) (*govmomi.Client, error) {
+	if rootCAs != nil {
+		insecure = false
+	}
+
 	soapClient := soap.NewClient(u, insecure)
+	if rootCAs != nil {
+		soapClient.DefaultTransport().TLSClientConfig.RootCAs = rootCAs
+	}
 	vimClient, err := vim25.NewClient(ctx, soapClient)
 	if err != nil {
 		return nil, err
@@ -368,7 +499,7 @@ func newClientWithKeepAlive(ctx context.Context, u *url.URL, insecure bool, keep
 	}
 
 	k := session.KeepAlive(c.Client.RoundTripper, time.Duration(keepAlive)*time.Minute)
-	c.Client.RoundTripper = k
+	c.Client.RoundTripper = &tracingSoapRoundTripper{next: k, server: u.Hostname()}
 
 	// Only login if the URL contains user information.
 	if u.User != nil {
@@ -0,0 +1,113 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// TestVimClient_restoreSession restores a persisted session of a vSphere
+// server whose certificate is issued by a private CA. The restored client
+// must verify the server with the CA bundle, and a session that cannot be
+// checked must lead to a new session rather than to an error.
+func (at *AccTests) TestVimClient_restoreSession(t *testing.T) {
+	var requests int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessionPath := t.TempDir()
+	if err := os.Chmod(sessionPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{
+		User:           "administrator@vsphere.local",
+		Password:       "password",
+		VSphereServer:  u.Host,
+		Persist:        true,
+		VimSessionPath: sessionPath,
+		KeepAlive:      10,
+		APITimeout:     5 * time.Second,
+		RootCAs:        server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+	}
+
+	vimURL, err := c.vimURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionManager := types.ManagedObjectReference{Type: "SessionManager", Value: "SessionManager"}
+	saved := &govmomi.Client{Client: &vim25.Client{
+		Client:         soap.NewClient(vimURL, false),
+		ServiceContent: types.ServiceContent{SessionManager: &sessionManager},
+	}}
+	if err := c.SaveVimClient(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := c.LoadVimClient()
+	if err != nil {
+		t.Fatalf("expected a session that cannot be checked to lead to a new session, got %s", err)
+	}
+	if client != nil {
+		t.Error("expected no restored client")
+	}
+	if atomic.LoadInt32(&requests) == 0 {
+		t.Error("expected the restored client to verify the server with the CA bundle")
+	}
+}
+
+// TestVimClient_passwordCommand configures the provider with a vSphere
+// password command that fails. The command must run only when vSphere is
+// first used, so that the runs that do not need vSphere succeed.
+func (at *AccTests) TestVimClient_passwordCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test commands require a POSIX shell")
+	}
+
+	marker := filepath.Join(t.TempDir(), "ran")
+	p := Provider()
+	diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"vcda_ip":                  "10.0.0.10",
+		"local_password":           "password",
+		"vsphere_server":           "vc.lab.local",
+		"vsphere_user":             "administrator@vsphere.local",
+		"vsphere_password_command": "touch " + marker + "; echo denied >&2; exit 1",
+	}))
+	if diags.HasError() {
+		t.Fatalf("expected the provider to be configured without running the password command: %v", diags)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("expected the password command not to run before vSphere is used")
+	}
+
+	if _, err := p.Meta().(*Client).vSphere(); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("expected the first use of vSphere to fail with the command error, got: %v", err)
+	}
+}
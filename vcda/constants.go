@@ -16,6 +16,8 @@ const (
 	ContentTypeHeaderValue = "application/json"
 	AcceptHeaderValue      = "application/vnd.vmware." + APIVersion + "+json;charset=UTF-8"
 	UserType               = "localUser"
+	SsoUserType            = "ssoCredentials"
+	VcdUserType            = "vcdCredentials"
 	UserAgentValue         = "vcda-terraform-provider/" + APIVersion
 
 	ManagerCertExtraConfigKey    = "guestinfo.manager.certificate"
@@ -30,6 +32,21 @@ const (
 	VspherePassword           = "VSPHERE_PASSWORD"
 	VsphereServer             = "VSPHERE_SERVER"
 	VsphereAllowUnverifiedSSL = "VSPHERE_ALLOW_UNVERIFIED_SSL"
+	VsphereVimKeepAlive       = "VSPHERE_VIM_KEEP_ALIVE"
+	VspherePersistSession     = "VSPHERE_PERSIST_SESSION"
+	VsphereVimSessionPath     = "VSPHERE_VIM_SESSION_PATH"
+	VsphereClientDebug        = "VSPHERE_CLIENT_DEBUG"
+	VsphereClientDebugPath    = "VSPHERE_CLIENT_DEBUG_PATH"
+	APITimeout                = "VCDA_API_TIMEOUT"
+	TracesFile                = "VCDA_TRACES_FILE"
+	CABundle                  = "VCDA_CA_BUNDLE"
+	CABundleFile              = "VCDA_CA_BUNDLE_FILE"
+	CertificatePinning        = "VCDA_CERTIFICATE_PINNING"
+	AuthType                  = "VCDA_AUTH_TYPE"
+	AuthUser                  = "VCDA_AUTH_USER"
+	AuthPassword              = "VCDA_AUTH_PASSWORD"
+	Profile                   = "VCDA_PROFILE"
+	ConfigFile                = "VCDA_CONFIG_FILE"
 	DatacenterID              = "DC_ID"
 	CloudVMName               = "CLOUD_VM_NAME"
 	ManagerVMName             = "MANAGER_VM_NAME"
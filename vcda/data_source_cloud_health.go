@@ -22,9 +22,10 @@ func dataSourceVcdaCloudHealth() *schema.Resource {
 		},
 		Schema: map[string]*schema.Schema{
 			"service_cert": {
-				Type:        schema.TypeString,
-				Description: "The service certificate.",
-				Required:    true,
+				Type: schema.TypeString,
+				Description: "The service certificate. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
 			},
 			// Computed
 			"id": {
@@ -217,30 +218,31 @@ func dataSourceVcdaCloudHealth() *schema.Resource {
 	}
 }
 
-func dataSourceVcdaCloudHealthRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func dataSourceVcdaCloudHealthRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*Client)
 
 	serviceCert := d.Get("service_cert").(string)
 
-	taskID, err := c.getCloudHealth(serviceCert)
+	taskID, err := c.getCloudHealth(ctx, serviceCert)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
 	d.SetId(*taskID)
 
-	err = retryHealthTask(c, d, serviceCert, taskID)
+	err = retryHealthTask(ctx, c, d, serviceCert, taskID)
 
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	return getCloudHealthInfo(c, d)
+	return getCloudHealthInfo(ctx, c, d)
 }
 
-func retryHealthTask(c *Client, d *schema.ResourceData, serviceCert string, taskID *string) error {
-	err := retry.RetryContext(context.Background(), d.Timeout(schema.TimeoutRead), func() *retry.RetryError {
-		task, err := c.getTask(serviceCert, *taskID)
+func retryHealthTask(ctx context.Context, c *Client, d *schema.ResourceData, serviceCert string, taskID *string) error {
+	pollCtx, span := startTaskPollSpan(ctx, "cloud_health", *taskID)
+	err := retry.RetryContext(pollCtx, d.Timeout(schema.TimeoutRead), func() *retry.RetryError {
+		task, err := c.getTask(pollCtx, serviceCert, *taskID)
 
 		if err != nil {
 			return retry.NonRetryableError(err)
@@ -256,14 +258,15 @@ func retryHealthTask(c *Client, d *schema.ResourceData, serviceCert string, task
 
 		return nil
 	})
+	endSpan(span, err)
 	return err
 }
 
-func getHealthTaskResult(c *Client, d *schema.ResourceData) (map[string]interface{}, error) {
+func getHealthTaskResult(ctx context.Context, c *Client, d *schema.ResourceData) (map[string]interface{}, error) {
 	serviceCert := d.Get("service_cert").(string)
 	taskID := d.Get("id").(string)
 
-	task, err := c.getTask(serviceCert, taskID)
+	task, err := c.getTask(ctx, serviceCert, taskID)
 	if err != nil {
 		return nil, err
 	}
@@ -276,10 +279,10 @@ func getHealthTaskResult(c *Client, d *schema.ResourceData) (map[string]interfac
 	return health, nil
 }
 
-func getCloudHealthInfo(c *Client, d *schema.ResourceData) diag.Diagnostics {
+func getCloudHealthInfo(ctx context.Context, c *Client, d *schema.ResourceData) diag.Diagnostics {
 	var diags diag.Diagnostics
 
-	health, err := getHealthTaskResult(c, d)
+	health, err := getHealthTaskResult(ctx, c, d)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -40,6 +40,13 @@ func (at *AccTests) TestAccVcdaDataSourceCloudHealth_basic(t *testing.T) {
 					resource.TestCheckResourceAttrSet("data.vcda_cloud_health.cloud_health", "manager_id"),
 				),
 			},
+			{
+				Config: testAccVcdaCloudHealthConfigDiscoveredCert(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.vcda_cloud_health.cloud_health", "id"),
+					resource.TestCheckResourceAttrSet("data.vcda_cloud_health.cloud_health", "instance_id"),
+				),
+			},
 		},
 	})
 }
@@ -69,3 +76,22 @@ data "vcda_cloud_health" "cloud_health" {
 		os.Getenv(CloudVMName),
 	)
 }
+
+func testAccVcdaCloudHealthConfigDiscoveredCert() string {
+	return fmt.Sprintf(`
+provider "vcda" {
+  appliance {
+    address       = %q
+    vm_name       = %q
+    datacenter_id = %q
+  }
+}
+
+data "vcda_cloud_health" "cloud_health" {
+}
+`,
+		os.Getenv(VcdaIP),
+		os.Getenv(CloudVMName),
+		os.Getenv(DatacenterID),
+	)
+}
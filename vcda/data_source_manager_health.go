@@ -21,9 +21,10 @@ func dataSourceVcdaManagerHealth() *schema.Resource {
 		},
 		Schema: map[string]*schema.Schema{
 			"service_cert": {
-				Type:        schema.TypeString,
-				Description: "The certificate of the Cloud Director/vCenter Replication Manager Service.",
-				Required:    true,
+				Type: schema.TypeString,
+				Description: "The certificate of the Cloud Director/vCenter Replication Manager Service. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
 			},
 			"manager_id": {
 				Type:        schema.TypeString,
@@ -240,35 +241,35 @@ func dataSourceVcdaManagerHealth() *schema.Resource {
 	}
 }
 
-func dataSourceVcdaManagerHealthRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func dataSourceVcdaManagerHealthRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*Client)
 
 	serviceCert := d.Get("service_cert").(string)
 
-	taskID, err := c.getCloudHealth(serviceCert)
+	taskID, err := c.getCloudHealth(ctx, serviceCert)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
 	d.SetId(*taskID)
 
-	err = retryHealthTask(c, d, serviceCert, taskID)
+	err = retryHealthTask(ctx, c, d, serviceCert, taskID)
 
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	return getManagerHealthInfo(c, d)
+	return getManagerHealthInfo(ctx, c, d)
 }
 
-func getManagerHealthInfo(c *Client, d *schema.ResourceData) diag.Diagnostics {
+func getManagerHealthInfo(ctx context.Context, c *Client, d *schema.ResourceData) diag.Diagnostics {
 	var diags diag.Diagnostics
 
 	serviceCert := d.Get("service_cert").(string)
 	managerID := d.Get("manager_id").(string)
 	taskID := d.Get("id").(string)
 
-	task, err := c.getTask(serviceCert, taskID)
+	task, err := c.getTask(ctx, serviceCert, taskID)
 	if err != nil {
 		return diag.FromErr(err)
 	}
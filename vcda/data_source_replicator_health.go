@@ -21,9 +21,10 @@ func dataSourceVcdaReplicatorHealth() *schema.Resource {
 		},
 		Schema: map[string]*schema.Schema{
 			"service_cert": {
-				Type:        schema.TypeString,
-				Description: "The certificate of the Cloud Director/vCenter Replication Manager Service.",
-				Required:    true,
+				Type: schema.TypeString,
+				Description: "The certificate of the Cloud Director/vCenter Replication Manager Service. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
 			},
 			"replicator_id": {
 				Type:        schema.TypeString,
@@ -247,33 +248,33 @@ func dataSourceVcdaReplicatorHealth() *schema.Resource {
 	}
 }
 
-func dataSourceVcdaReplicatorHealthRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func dataSourceVcdaReplicatorHealthRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*Client)
 
 	serviceCert := d.Get("service_cert").(string)
 
-	taskID, err := c.getCloudHealth(serviceCert)
+	taskID, err := c.getCloudHealth(ctx, serviceCert)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
 	d.SetId(*taskID)
 
-	err = retryHealthTask(c, d, serviceCert, taskID)
+	err = retryHealthTask(ctx, c, d, serviceCert, taskID)
 
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	return getReplicatorHealthInfo(c, d)
+	return getReplicatorHealthInfo(ctx, c, d)
 }
 
-func getReplicatorHealthInfo(c *Client, d *schema.ResourceData) diag.Diagnostics {
+func getReplicatorHealthInfo(ctx context.Context, c *Client, d *schema.ResourceData) diag.Diagnostics {
 	var diags diag.Diagnostics
 
 	replicatorID := d.Get("replicator_id").(string)
 
-	health, err := getHealthTaskResult(c, d)
+	health, err := getHealthTaskResult(ctx, c, d)
 	if err != nil {
 		return diag.FromErr(err)
 	}
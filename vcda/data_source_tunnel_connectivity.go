@@ -21,9 +21,10 @@ func dataSourceVcdaTunnelConnectivity() *schema.Resource {
 		},
 		Schema: map[string]*schema.Schema{
 			"service_cert": {
-				Type:        schema.TypeString,
-				Description: "The certificate of the Cloud Director/vCenter Replication Manager Service.",
-				Required:    true,
+				Type: schema.TypeString,
+				Description: "The certificate of the Cloud Director/vCenter Replication Manager Service. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
 			},
 			"tunnel_id": {
 				Type:        schema.TypeString,
@@ -71,33 +72,33 @@ func dataSourceVcdaTunnelConnectivity() *schema.Resource {
 	}
 }
 
-func dataSourceVcdaTunnelConnectivityRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func dataSourceVcdaTunnelConnectivityRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*Client)
 
 	serviceCert := d.Get("service_cert").(string)
 
-	taskID, err := c.getCloudHealth(serviceCert)
+	taskID, err := c.getCloudHealth(ctx, serviceCert)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
 	d.SetId(*taskID)
 
-	err = retryHealthTask(c, d, serviceCert, taskID)
+	err = retryHealthTask(ctx, c, d, serviceCert, taskID)
 
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	return getTunnelConnectivityInfo(c, d)
+	return getTunnelConnectivityInfo(ctx, c, d)
 }
 
-func getTunnelConnectivityInfo(c *Client, d *schema.ResourceData) diag.Diagnostics {
+func getTunnelConnectivityInfo(ctx context.Context, c *Client, d *schema.ResourceData) diag.Diagnostics {
 	var diags diag.Diagnostics
 
 	tunnelID := d.Get("tunnel_id").(string)
 
-	health, err := getHealthTaskResult(c, d)
+	health, err := getHealthTaskResult(ctx, c, d)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -0,0 +1,221 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func dataSourceVcdaAppliances() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceVcdaAppliancesRead,
+		Schema: map[string]*schema.Schema{
+			"datacenter_id": {
+				Type: schema.TypeString,
+				Description: "The managed object ID of the datacenter to scan for appliances. " +
+					"When not set, all datacenters are scanned.",
+				Optional: true,
+			},
+			"folder": {
+				Type: schema.TypeString,
+				Description: "The inventory path of the VM folder to scan for appliances, " +
+					"relative to `datacenter_id` when given. When not set, the whole datacenter is scanned.",
+				Optional: true,
+			},
+			"role": {
+				Type:         schema.TypeString,
+				Description:  "Return only the appliances of this role: manager, cloud, tunnel or replicator.",
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"manager", "cloud", "tunnel", "replicator"}, false),
+			},
+
+			// computed
+			"appliances": {
+				Type:        schema.TypeList,
+				Description: "The appliances found, sorted by name.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"role": {
+							Type:        schema.TypeString,
+							Description: "The appliance role, detected from the certificate extraConfig key of the VM.",
+							Computed:    true,
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Description: "The VM name of the appliance.",
+							Computed:    true,
+						},
+						"moid": {
+							Type:        schema.TypeString,
+							Description: "The managed object ID of the appliance VM.",
+							Computed:    true,
+						},
+						"ip_addresses": {
+							Type:        schema.TypeList,
+							Description: "The guest IP addresses of the appliance VM, as reported by VMware Tools.",
+							Computed:    true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"power_state": {
+							Type:        schema.TypeString,
+							Description: "The power state of the appliance VM.",
+							Computed:    true,
+						},
+						"certificate": {
+							Type: schema.TypeString,
+							Description: "The service certificate of the appliance in a base64-encoded DER format, " +
+								"usable as `service_cert`.",
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceVcdaAppliancesRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*Client)
+	vimClient, err := c.vSphere()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	client := vimClient.vimClient
+
+	finder := find.NewFinder(client.Client, false)
+	root := client.ServiceContent.RootFolder
+
+	if dcID, ok := d.GetOk("datacenter_id"); ok {
+		dc, err := datacenterFromID(ctx, vimClient, dcID.(string))
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("cannot locate datacenter: %s", err))
+		}
+		finder.SetDatacenter(dc)
+		root = dc.Reference()
+	}
+
+	if folderPath, ok := d.GetOk("folder"); ok {
+		folder, err := finder.Folder(ctx, folderPath.(string))
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("cannot locate folder: %s", err))
+		}
+		root = folder.Reference()
+	}
+
+	vms, err := appliancesUnder(ctx, vimClient, root)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	roleFilter := d.Get("role").(string)
+
+	appliances := make([]interface{}, 0)
+	for i := range vms {
+		vm := &vms[i]
+
+		var role, cert string
+		for _, r := range applianceRoles {
+			if cert = extraConfigValue(vm, r.ExtraConfigKey); cert != "" {
+				role = r.Role
+				break
+			}
+		}
+		if role == "" || (roleFilter != "" && roleFilter != role) {
+			continue
+		}
+
+		appliances = append(appliances, map[string]interface{}{
+			"role":         role,
+			"name":         vm.Name,
+			"moid":         vm.Reference().Value,
+			"ip_addresses": guestIPAddresses(vm),
+			"power_state":  string(vm.Runtime.PowerState),
+			"certificate":  cert,
+		})
+	}
+
+	sort.SliceStable(appliances, func(i, j int) bool {
+		return appliances[i].(map[string]interface{})["name"].(string) < appliances[j].(map[string]interface{})["name"].(string)
+	})
+
+	log.Printf("[DEBUG] Found %d appliance(s) under %s", len(appliances), root.Value)
+
+	d.SetId(root.Value)
+
+	if err := d.Set("appliances", appliances); err != nil {
+		return diag.FromErr(fmt.Errorf("error setting appliances field: %s", err))
+	}
+
+	return diags
+}
+
+// appliancesUnder retrieves the VMs under root with the properties needed to
+// identify the VCDA appliances among them.
+func appliancesUnder(ctx context.Context, vimClient *VimClient, root types.ManagedObjectReference) ([]mo.VirtualMachine, error) {
+	client := vimClient.vimClient
+
+	ctx, cancel := context.WithTimeout(ctx, vimClient.timeout)
+	defer cancel()
+
+	v, err := view.NewManager(client.Client).CreateContainerView(ctx, root, []string{"VirtualMachine"}, true)
+	if err != nil {
+		return nil, fmt.Errorf("error creating container view: %s", err)
+	}
+	defer func() {
+		if err := v.Destroy(context.Background()); err != nil {
+			log.Printf("[DEBUG] Could not destroy container view: %s", err)
+		}
+	}()
+
+	var vms []mo.VirtualMachine
+	props := []string{"name", "config.extraConfig", "guest.ipAddress", "guest.net", "runtime.powerState"}
+	if err := v.Retrieve(ctx, []string{"VirtualMachine"}, props, &vms); err != nil {
+		return nil, fmt.Errorf("error retrieving virtual machines: %s", err)
+	}
+
+	return vms, nil
+}
+
+// guestIPAddresses returns the IP addresses reported by VMware Tools, starting
+// with the primary one and without duplicates.
+func guestIPAddresses(vm *mo.VirtualMachine) []string {
+	ips := make([]string, 0)
+	if vm.Guest == nil {
+		return ips
+	}
+
+	seen := make(map[string]bool)
+	add := func(ip string) {
+		if ip != "" && !seen[ip] {
+			seen[ip] = true
+			ips = append(ips, ip)
+		}
+	}
+
+	add(vm.Guest.IpAddress)
+	for _, nic := range vm.Guest.Net {
+		for _, ip := range nic.IpAddress {
+			add(ip)
+		}
+	}
+
+	return ips
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"os"
+	"testing"
+)
+
+func (at *AccTests) TestAccVcdaDataSourceAppliances_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testProviders(),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVcdaAppliancesConfigBasic(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.vcda_appliances.appliances", "id", os.Getenv(DatacenterID)),
+					resource.TestCheckResourceAttrSet("data.vcda_appliances.appliances", "appliances.#"),
+					resource.TestCheckResourceAttr("data.vcda_appliances.cloud", "appliances.#", "1"),
+					resource.TestCheckResourceAttr("data.vcda_appliances.cloud", "appliances.0.role", "cloud"),
+					resource.TestCheckResourceAttr("data.vcda_appliances.cloud", "appliances.0.name", os.Getenv(CloudVMName)),
+					resource.TestCheckResourceAttrSet("data.vcda_appliances.cloud", "appliances.0.certificate"),
+				),
+			},
+		},
+	})
+}
+
+func testAccVcdaAppliancesConfigBasic() string {
+	return fmt.Sprintf(`
+data "vcda_appliances" "appliances" {
+  datacenter_id = %q
+}
+
+data "vcda_appliances" "cloud" {
+  datacenter_id = %q
+  role          = "cloud"
+}`,
+		os.Getenv(DatacenterID),
+		os.Getenv(DatacenterID),
+	)
+}
@@ -13,12 +13,20 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
+// thumbprintPrefix is the prefix VCDA expects in front of SHA-256 thumbprints.
+const thumbprintPrefix = "SHA-256:"
+
 func dataSourceVcdaRemoteServicesThumbprint() *schema.Resource {
 	return &schema.Resource{
 		ReadContext: dataSourceVcdaRemoteServicesThumbprintRead,
@@ -26,7 +34,8 @@ func dataSourceVcdaRemoteServicesThumbprint() *schema.Resource {
 			"address": {
 				Type: schema.TypeString,
 				Description: "The address of the remote appliance/service. " +
-					"**NOTE:** this method produces a thumbprint that is not verified nor safe for use.",
+					"**NOTE:** unless `verify`, `ca_bundle`, `ca_bundle_file` or `expected_thumbprint` is set, " +
+					"this method produces a thumbprint that is not verified nor safe for use.",
 				Optional:      true,
 				ConflictsWith: []string{"pem_file"},
 				RequiredWith:  []string{"port"},
@@ -36,91 +45,250 @@ func dataSourceVcdaRemoteServicesThumbprint() *schema.Resource {
 				Description: "The port of the remote appliance/service. Use only with `address`.",
 				Optional:    true,
 			},
+			"server_name": {
+				Type: schema.TypeString,
+				Description: "The server name sent in the TLS handshake (SNI) and used for host name verification. " +
+					"Use only with `address`. Defaults to `address`.",
+				Optional:     true,
+				RequiredWith: []string{"address"},
+			},
 			"pem_file": {
 				Type: schema.TypeString,
-				Description: "The name of the file that contains the last certificate " +
-					"in the chain (end entity cert) of the remote appliance/service in PEM format. " +
+				Description: "The name of the file that contains the certificate chain " +
+					"of the remote appliance/service in PEM format, starting with the end entity certificate. " +
 					"On creation, include either `pem_file` or `address`.",
 				Optional:      true,
 				ConflictsWith: []string{"address"},
 			},
+			"certificate_index": {
+				Type: schema.TypeInt,
+				Description: "The position in the certificate chain of the certificate to fingerprint. " +
+					"`0` is the end entity certificate, `1` its issuer and so on.",
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"verify": {
+				Type: schema.TypeBool,
+				Description: "When set, the certificate chain is verified against the system trust store, " +
+					"or against `ca_bundle`/`ca_bundle_file` when given. Reading fails if the verification does not pass.",
+				Optional: true,
+				Default:  false,
+			},
+			"ca_bundle": {
+				Type:          schema.TypeString,
+				Description:   "PEM encoded CA certificates to verify the certificate chain against. Implies `verify`.",
+				Optional:      true,
+				ConflictsWith: []string{"ca_bundle_file"},
+			},
+			"ca_bundle_file": {
+				Type: schema.TypeString,
+				Description: "The name of a file with PEM encoded CA certificates to verify the certificate chain against. " +
+					"Implies `verify`.",
+				Optional:      true,
+				ConflictsWith: []string{"ca_bundle"},
+			},
+			"expected_thumbprint": {
+				Type: schema.TypeString,
+				Description: "The expected SHA-256 thumbprint of the selected certificate, with or without " +
+					"the `SHA-256:` prefix. Reading fails if the computed thumbprint does not match.",
+				Optional: true,
+			},
+
+			// computed
+			"thumbprint": {
+				Type:        schema.TypeString,
+				Description: "The SHA-256 thumbprint of the selected certificate, prefixed with `SHA-256:`.",
+				Computed:    true,
+			},
+			"thumbprint_bare": {
+				Type:        schema.TypeString,
+				Description: "The SHA-256 thumbprint of the selected certificate, without the `SHA-256:` prefix.",
+				Computed:    true,
+			},
+			"verified": {
+				Type:        schema.TypeBool,
+				Description: "Flag indicating whether the certificate was verified against a trust store or a pinned thumbprint.",
+				Computed:    true,
+			},
 		},
 	}
 }
 
-func dataSourceVcdaRemoteServicesThumbprintRead(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+func dataSourceVcdaRemoteServicesThumbprintRead(ctx context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 
 	address := d.Get("address").(string)
 	port := d.Get("port").(string)
+	serverName := d.Get("server_name").(string)
 	pemFile := d.Get("pem_file").(string)
 
+	if address == "" && pemFile == "" {
+		return diag.Errorf(`either "address" or "pem_file" should be given`)
+	}
+
+	roots, err := loadCABundle(d.Get("ca_bundle").(string), d.Get("ca_bundle_file").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var chain []*x509.Certificate
 	if address != "" {
-		err := computeHostThumbprint(address, port, d)
+		chain, err = fetchPeerCertificates(ctx, address, port, serverName)
+	} else {
+		chain, err = readCertificatesFromFile(pemFile)
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-		if err != nil {
+	verified := false
+	if d.Get("verify").(bool) || roots != nil {
+		dnsName := serverName
+		if dnsName == "" && pemFile == "" {
+			dnsName = address
+		}
+		if err := verifyCertificateChain(chain, roots, dnsName); err != nil {
 			return diag.FromErr(err)
 		}
+		verified = true
+	}
+
+	index := d.Get("certificate_index").(int)
+	if index >= len(chain) {
+		return diag.Errorf("certificate_index %d is out of range, the certificate chain contains %d certificate(s)", index, len(chain))
 	}
 
-	if pemFile != "" {
-		err := computeThumbprintFromFile(pemFile, d)
+	thumbprint := formatFingerprint(sha256.Sum256(chain[index].Raw))
 
-		if err != nil {
-			return diag.FromErr(err)
+	if expected := d.Get("expected_thumbprint").(string); expected != "" {
+		if !thumbprintsEqual(expected, thumbprint) {
+			return diag.Errorf("thumbprint mismatch: expected %s, but the certificate has %s", expected, thumbprint)
 		}
+		verified = true
+	}
+
+	d.SetId(thumbprint)
+
+	if err := d.Set("thumbprint", thumbprint); err != nil {
+		return diag.FromErr(fmt.Errorf("error setting thumbprint field: %s", err))
+	}
+
+	if err := d.Set("thumbprint_bare", strings.TrimPrefix(thumbprint, thumbprintPrefix)); err != nil {
+		return diag.FromErr(fmt.Errorf("error setting thumbprint_bare field: %s", err))
+	}
+
+	if err := d.Set("verified", verified); err != nil {
+		return diag.FromErr(fmt.Errorf("error setting verified field: %s", err))
 	}
 
 	return diags
 }
 
-func computeHostThumbprint(address string, port string, d *schema.ResourceData) error {
-	config := &tls.Config{}
-	config.InsecureSkipVerify = true
+// fetchPeerCertificates returns the certificate chain presented by the remote
+// service. The handshake itself does not verify the chain, this is left to
+// verifyCertificateChain so that the chain can be fingerprinted either way.
+func fetchPeerCertificates(ctx context.Context, address string, port string, serverName string) ([]*x509.Certificate, error) {
+	if serverName == "" {
+		serverName = address
+	}
 
-	conn, err := tls.Dial("tcp", address+":"+port, config)
-	if err != nil {
-		return err
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: 30 * time.Second},
+		Config: &tls.Config{
+			ServerName: serverName,
+			// the chain is verified by verifyCertificateChain when requested
+			InsecureSkipVerify: true, // #nosec G402
+		},
 	}
-	cert := conn.ConnectionState().PeerCertificates[0]
 
-	fingerprint := sha256.Sum256(cert.Raw)
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, port))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
 
-	thumbprint := formatFingerprint(fingerprint)
-	d.SetId(thumbprint)
+	chain := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%s:%s did not present any certificates", address, port)
+	}
 
-	return nil
+	return chain, nil
 }
 
-func computeThumbprintFromFile(pemFile string, d *schema.ResourceData) error {
-	cert, err := os.ReadFile(pemFile)
+// readCertificatesFromFile parses every PEM certificate block in pemFile.
+func readCertificatesFromFile(pemFile string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(filepath.Clean(pemFile))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	block, _ := pem.Decode(cert)
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
 
-	if block == nil {
-		return fmt.Errorf("failed to decode PEM file - invalid PEM format")
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PEM file: %s", err)
+		}
+		chain = append(chain, cert)
 	}
 
-	caCert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		return fmt.Errorf("failed to parse PEM file")
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("failed to decode PEM file - invalid PEM format")
 	}
 
-	fingerprint := sha256.Sum256(caCert.Raw)
+	return chain, nil
+}
+
+// verifyCertificateChain verifies the end entity certificate of chain against
+// roots, using the rest of the chain as intermediates. A nil roots pool means
+// the system trust store. The host name is only checked when dnsName is set.
+func verifyCertificateChain(chain []*x509.Certificate, roots *x509.CertPool, dnsName string) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
 
-	thumbprint := formatFingerprint(fingerprint)
-	d.SetId(thumbprint)
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       dnsName,
+	}
+
+	if _, err := chain[0].Verify(opts); err != nil {
+		return fmt.Errorf("certificate verification failed: %s", err)
+	}
 
 	return nil
 }
 
+// thumbprintsEqual compares two SHA-256 thumbprints ignoring the SHA-256:
+// prefix, the colon separators and the case.
+func thumbprintsEqual(a string, b string) bool {
+	return normalizeThumbprint(a) == normalizeThumbprint(b)
+}
+
+func normalizeThumbprint(thumbprint string) string {
+	thumbprint = strings.TrimSpace(thumbprint)
+	if len(thumbprint) >= len(thumbprintPrefix) && strings.EqualFold(thumbprint[:len(thumbprintPrefix)], thumbprintPrefix) {
+		thumbprint = thumbprint[len(thumbprintPrefix):]
+	}
+
+	return strings.ToUpper(strings.ReplaceAll(thumbprint, ":", ""))
+}
+
 func formatFingerprint(fingerprint [32]byte) string {
 	var buf bytes.Buffer
 
-	buf.WriteString("SHA-256:")
+	buf.WriteString(thumbprintPrefix)
 	for i, f := range fingerprint {
 		if i > 0 {
 			_, _ = fmt.Fprintf(&buf, ":")
@@ -21,6 +21,16 @@ func (at *AccTests) TestAccVcdaDataSourceRemoteServicesThumbprint_basic(t *testi
 				Config: testAccVcdaRemoteServicesThumbprintConfigBasic(),
 				Check: resource.ComposeTestCheckFunc(
 					resource.TestCheckResourceAttrSet("data.vcda_remote_services_thumbprint.thumbprint", "id"),
+					resource.TestCheckResourceAttr("data.vcda_remote_services_thumbprint.thumbprint", "verified", "false"),
+				),
+			},
+			{
+				Config: testAccVcdaRemoteServicesThumbprintConfigPinned(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.vcda_remote_services_thumbprint.pinned", "id",
+						"data.vcda_remote_services_thumbprint.thumbprint", "id"),
+					resource.TestCheckResourceAttrSet("data.vcda_remote_services_thumbprint.pinned", "thumbprint_bare"),
+					resource.TestCheckResourceAttr("data.vcda_remote_services_thumbprint.pinned", "verified", "true"),
 				),
 			},
 		},
@@ -37,3 +47,13 @@ data "vcda_remote_services_thumbprint" "thumbprint" {
 		os.Getenv(VcdaIP),
 	)
 }
+
+func testAccVcdaRemoteServicesThumbprintConfigPinned() string {
+	return testAccVcdaRemoteServicesThumbprintConfigBasic() + `
+data "vcda_remote_services_thumbprint" "pinned" {
+  address             = data.vcda_remote_services_thumbprint.thumbprint.address
+  port                = "443"
+  expected_thumbprint = data.vcda_remote_services_thumbprint.thumbprint.thumbprint_bare
+}
+`
+}
@@ -7,11 +7,14 @@ package vcda
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"log"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
@@ -19,101 +22,251 @@ import (
 	"github.com/vmware/govmomi/vim25/types"
 )
 
+// applianceRoles maps the appliance roles to the extraConfig keys holding
+// their certificates, in the order the roles are detected.
+var applianceRoles = []struct {
+	Role           string
+	ExtraConfigKey string
+}{
+	{"cloud", CloudCertExtraConfigKey},
+	{"manager", ManagerCertExtraConfigKey},
+	{"replicator", ReplicatorCertExtraConfigKey},
+	{"tunnel", TunnelCertExtraConfigKey},
+}
+
+// vmLookupKeys are the mutually exclusive arguments for looking up a VM.
+var vmLookupKeys = []string{"name", "instance_uuid", "bios_uuid", "moid", "ip_address"}
+
 func dataSourceVcdaServiceCert() *schema.Resource {
 	return &schema.Resource{
 		ReadContext: dataSourceVcdaServiceCertRead,
 		Schema: map[string]*schema.Schema{
 			"datacenter_id": {
-				Type:        schema.TypeString,
-				Description: "The managed object ID of the datacenter where the virtual machine resides in.",
-				Required:    true,
+				Type: schema.TypeString,
+				Description: "The managed object ID of the datacenter where the virtual machine resides in. " +
+					"When not set, all datacenters are searched, except for a lookup by `name` that uses the default datacenter.",
+				Optional: true,
 			},
 			"name": {
-				Type:        schema.TypeString,
-				Description: "The VM name of the appliance.",
-				Required:    true,
+				Type:         schema.TypeString,
+				Description:  "The VM name or inventory path of the appliance.",
+				Optional:     true,
+				ExactlyOneOf: vmLookupKeys,
+			},
+			"instance_uuid": {
+				Type:         schema.TypeString,
+				Description:  "The instance UUID of the appliance VM.",
+				Optional:     true,
+				ExactlyOneOf: vmLookupKeys,
+			},
+			"bios_uuid": {
+				Type:         schema.TypeString,
+				Description:  "The BIOS UUID of the appliance VM.",
+				Optional:     true,
+				ExactlyOneOf: vmLookupKeys,
+			},
+			"moid": {
+				Type:         schema.TypeString,
+				Description:  "The managed object ID of the appliance VM.",
+				Optional:     true,
+				ExactlyOneOf: vmLookupKeys,
+			},
+			"ip_address": {
+				Type:         schema.TypeString,
+				Description:  "The guest IP address of the appliance VM, as reported by VMware Tools.",
+				Optional:     true,
+				ExactlyOneOf: vmLookupKeys,
 			},
 			"type": {
 				Type: schema.TypeString,
-				Description: "The type of the appliance role: manager, cloud, tunnel, replicator. " +
-					"When not set returns an error.",
-				Required: true,
+				Description: "The type of the appliance role: manager, cloud, tunnel, replicator or auto. " +
+					"With auto, the role is detected from the certificate extraConfig key present on the VM.",
+				Optional:     true,
+				Default:      "auto",
+				ValidateFunc: validation.StringInSlice([]string{"manager", "cloud", "tunnel", "replicator", "auto"}, false),
+			},
+
+			// computed
+			"detected_type": {
+				Type:        schema.TypeString,
+				Description: "The appliance role whose certificate was returned.",
+				Computed:    true,
+			},
+			"vm_ip_address": {
+				Type:        schema.TypeString,
+				Description: "The primary guest IP address of the appliance VM.",
+				Computed:    true,
+			},
+			"power_state": {
+				Type:        schema.TypeString,
+				Description: "The power state of the appliance VM.",
+				Computed:    true,
+			},
+			"thumbprint": {
+				Type:        schema.TypeString,
+				Description: "The SHA-256 thumbprint of the certificate, prefixed with `SHA-256:`.",
+				Computed:    true,
 			},
 		},
 	}
 }
 
-func dataSourceVcdaServiceCertRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func dataSourceVcdaServiceCertRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 	c := m.(*Client)
-	vimClient := c.VimClient
 
-	name := d.Get("name").(string)
-	vmType := d.Get("type").(string)
+	cert, err := lookupServiceCert(ctx, c, ServiceCertLookup{
+		DatacenterID: d.Get("datacenter_id").(string),
+		Name:         d.Get("name").(string),
+		InstanceUUID: d.Get("instance_uuid").(string),
+		BiosUUID:     d.Get("bios_uuid").(string),
+		MOID:         d.Get("moid").(string),
+		IPAddress:    d.Get("ip_address").(string),
+		Type:         d.Get("type").(string),
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(cert.Certificate)
+
+	if err := d.Set("detected_type", cert.DetectedType); err != nil {
+		return diag.FromErr(fmt.Errorf("error setting detected_type field: %s", err))
+	}
+
+	if err := d.Set("thumbprint", cert.Thumbprint); err != nil {
+		return diag.FromErr(fmt.Errorf("error setting thumbprint field: %s", err))
+	}
+
+	if err := d.Set("power_state", cert.PowerState); err != nil {
+		return diag.FromErr(fmt.Errorf("error setting power_state field: %s", err))
+	}
+
+	if err := d.Set("vm_ip_address", cert.VMIPAddress); err != nil {
+		return diag.FromErr(fmt.Errorf("error setting vm_ip_address field: %s", err))
+	}
+
+	return diags
+}
+
+// ServiceCertLookup identifies the appliance VM by exactly one of Name,
+// InstanceUUID, BiosUUID, MOID or IPAddress.
+type ServiceCertLookup struct {
+	DatacenterID string
+	Name         string
+	InstanceUUID string
+	BiosUUID     string
+	MOID         string
+	IPAddress    string
+	Type         string
+}
+
+// ServiceCert is the service certificate of an appliance VM.
+type ServiceCert struct {
+	Certificate  string
+	DetectedType string
+	Thumbprint   string
+	PowerState   string
+	VMIPAddress  string
+}
+
+// lookupServiceCert finds the appliance VM and reads the service
+// certificate of the requested role from its extraConfig.
+func lookupServiceCert(ctx context.Context, c *Client, lookup ServiceCertLookup) (*ServiceCert, error) {
+	vimClient, err := c.vSphere()
+	if err != nil {
+		return nil, err
+	}
+
+	vmType := lookup.Type
+	if vmType == "" {
+		vmType = "auto"
+	}
+
 	var vm *object.VirtualMachine
-	var err error
 
-	// TODO: implement looking for VM or template by UUID
-	log.Printf("[DEBUG] Looking for VM or template by name/path %q", name)
 	var dc *object.Datacenter
-	if dcID, ok := d.GetOk("datacenter_id"); ok {
-		dc, err = datacenterFromID(vimClient.vimClient, dcID.(string))
+	if lookup.DatacenterID != "" {
+		dc, err = datacenterFromID(ctx, vimClient, lookup.DatacenterID)
 		if err != nil {
-			return diag.FromErr(fmt.Errorf("cannot locate datacenter: %s", err))
+			return nil, fmt.Errorf("cannot locate datacenter: %s", err)
 		}
 		log.Printf("[DEBUG] Datacenter for VM/template search: %s", dc.InventoryPath)
 	}
-	vm, err = FromPath(vimClient.vimClient, name, dc)
+
+	switch {
+	case lookup.Name != "":
+		log.Printf("[DEBUG] Looking for VM or template by name/path %q", lookup.Name)
+		vm, err = FromPath(ctx, vimClient, lookup.Name, dc)
+	case lookup.InstanceUUID != "":
+		log.Printf("[DEBUG] Looking for VM by instance UUID %q", lookup.InstanceUUID)
+		vm, err = FromUUID(ctx, vimClient, lookup.InstanceUUID, true, dc)
+	case lookup.BiosUUID != "":
+		log.Printf("[DEBUG] Looking for VM by BIOS UUID %q", lookup.BiosUUID)
+		vm, err = FromUUID(ctx, vimClient, lookup.BiosUUID, false, dc)
+	case lookup.MOID != "":
+		log.Printf("[DEBUG] Looking for VM by managed object ID %q", lookup.MOID)
+		vm, err = FromMOID(ctx, vimClient, lookup.MOID)
+	case lookup.IPAddress != "":
+		log.Printf("[DEBUG] Looking for VM by IP address %q", lookup.IPAddress)
+		vm, err = FromIP(ctx, vimClient, lookup.IPAddress, dc)
+	default:
+		return nil, fmt.Errorf("one of name, instance_uuid, bios_uuid, moid or ip_address must be given")
+	}
 
 	if err != nil {
-		return diag.FromErr(fmt.Errorf("error fetching virtual machine: %s", err))
+		return nil, fmt.Errorf("error fetching virtual machine: %s", err)
 	}
 
-	props, err := Properties(vm)
+	props, err := Properties(ctx, vimClient, vm)
 	if err != nil {
-		return diag.FromErr(fmt.Errorf("error fetching virtual machine properties: %s", err))
+		return nil, fmt.Errorf("error fetching virtual machine properties: %s", err)
 	}
 
 	if props.Config == nil {
-		return diag.FromErr(fmt.Errorf("no configuration returned for virtual machine %q", vm.InventoryPath))
+		return nil, fmt.Errorf("no configuration returned for virtual machine %q", vm.InventoryPath)
 	}
 
-	extraConfig := props.Config.ExtraConfig
-
-	var extraConfigKey string
-
-	switch vmType {
-	case "manager":
-		extraConfigKey = ManagerCertExtraConfigKey
-	case "cloud":
-		extraConfigKey = CloudCertExtraConfigKey
-	case "tunnel":
-		extraConfigKey = TunnelCertExtraConfigKey
-	case "replicator":
-		extraConfigKey = ReplicatorCertExtraConfigKey
-	default:
-		return diag.Errorf("unknown VM appliance type")
+	var applianceCert, detectedType string
+	for _, role := range applianceRoles {
+		if vmType != "auto" && vmType != role.Role {
+			continue
+		}
+		if cert := extraConfigValue(props, role.ExtraConfigKey); cert != "" {
+			applianceCert, detectedType = cert, role.Role
+			break
+		}
 	}
 
-	var applianceCert string
-	for _, v := range extraConfig {
-		ov := v.GetOptionValue()
-		if ov.Key == extraConfigKey {
-			applianceCert = ov.Value.(string)
+	if applianceCert == "" {
+		if vmType == "auto" {
+			return nil, fmt.Errorf("no appliance certificate was found in virtual machine extraConfig of %q", vm.InventoryPath)
 		}
+		return nil, fmt.Errorf("appliance certificate for %s was not found in virtual machine extraConfig", vmType)
 	}
 
-	if applianceCert == "" {
-		return diag.FromErr(fmt.Errorf("applaince certificate for %s was not found in virtual machine extraConfig", extraConfigKey))
+	certData, err := base64.StdEncoding.DecodeString(applianceCert)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode appliance certificate: %s", err)
 	}
 
-	d.SetId(applianceCert)
+	var ipAddress string
+	if props.Guest != nil {
+		ipAddress = props.Guest.IpAddress
+	}
 
-	return diags
+	return &ServiceCert{
+		Certificate:  applianceCert,
+		DetectedType: detectedType,
+		Thumbprint:   formatFingerprint(sha256.Sum256(certData)),
+		PowerState:   string(props.Runtime.PowerState),
+		VMIPAddress:  ipAddress,
+	}, nil
 }
 
 // datacenterFromID locates a Datacenter by its managed object reference ID.
-func datacenterFromID(client *govmomi.Client, id string) (*object.Datacenter, error) {
+func datacenterFromID(ctx context.Context, vimClient *VimClient, id string) (*object.Datacenter, error) {
+	client := vimClient.vimClient
 	finder := find.NewFinder(client.Client, false)
 
 	ref := types.ManagedObjectReference{
@@ -121,7 +274,7 @@ func datacenterFromID(client *govmomi.Client, id string) (*object.Datacenter, er
 		Value: id,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	ctx, cancel := context.WithTimeout(ctx, vimClient.timeout)
 	defer cancel()
 	ds, err := finder.ObjectReference(ctx, ref)
 	if err != nil {
@@ -131,22 +284,111 @@ func datacenterFromID(client *govmomi.Client, id string) (*object.Datacenter, er
 }
 
 // FromPath returns a VirtualMachine via its supplied path.
-func FromPath(client *govmomi.Client, path string, dc *object.Datacenter) (*object.VirtualMachine, error) {
+func FromPath(ctx context.Context, vimClient *VimClient, path string, dc *object.Datacenter) (*object.VirtualMachine, error) {
+	client := vimClient.vimClient
 	finder := find.NewFinder(client.Client, false)
 	if dc != nil {
 		finder.SetDatacenter(dc)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	ctx, cancel := context.WithTimeout(ctx, vimClient.timeout)
 	defer cancel()
 	return finder.VirtualMachine(ctx, path)
 }
 
+// FromIP returns the VirtualMachine that reports the supplied IP address
+// through VMware Tools. When dc is nil all datacenters are searched.
+func FromIP(ctx context.Context, vimClient *VimClient, ip string, dc *object.Datacenter) (*object.VirtualMachine, error) {
+	client := vimClient.vimClient
+
+	ctx, cancel := context.WithTimeout(ctx, vimClient.timeout)
+	defer cancel()
+
+	ref, err := object.NewSearchIndex(client.Client).FindByIp(ctx, dc, ip, true)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		return nil, fmt.Errorf("virtual machine with IP address %s was not found", ip)
+	}
+
+	return virtualMachineFromReference(ctx, client, ref.Reference()), nil
+}
+
+// FromUUID returns the VirtualMachine with the supplied instance UUID, or
+// BIOS UUID when instanceUUID is false. When dc is nil all datacenters are
+// searched.
+func FromUUID(ctx context.Context, vimClient *VimClient, uuid string, instanceUUID bool, dc *object.Datacenter) (*object.VirtualMachine, error) {
+	client := vimClient.vimClient
+
+	ctx, cancel := context.WithTimeout(ctx, vimClient.timeout)
+	defer cancel()
+
+	ref, err := object.NewSearchIndex(client.Client).FindByUuid(ctx, dc, uuid, true, &instanceUUID)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		return nil, fmt.Errorf("virtual machine with UUID %s was not found", uuid)
+	}
+
+	return virtualMachineFromReference(ctx, client, ref.Reference()), nil
+}
+
+// FromMOID returns the VirtualMachine with the supplied managed object ID.
+func FromMOID(ctx context.Context, vimClient *VimClient, moid string) (*object.VirtualMachine, error) {
+	client := vimClient.vimClient
+
+	ctx, cancel := context.WithTimeout(ctx, vimClient.timeout)
+	defer cancel()
+
+	ref := types.ManagedObjectReference{
+		Type:  "VirtualMachine",
+		Value: moid,
+	}
+
+	if _, err := find.NewFinder(client.Client, false).ObjectReference(ctx, ref); err != nil {
+		return nil, fmt.Errorf("could not find virtual machine with id: %s: %s", moid, err)
+	}
+
+	return virtualMachineFromReference(ctx, client, ref), nil
+}
+
+// virtualMachineFromReference returns the VirtualMachine of ref, with its
+// inventory path filled in when it can be resolved.
+func virtualMachineFromReference(ctx context.Context, client *govmomi.Client, ref types.ManagedObjectReference) *object.VirtualMachine {
+	vm := object.NewVirtualMachine(client.Client, ref)
+	if element, err := find.NewFinder(client.Client, false).Element(ctx, ref); err == nil {
+		vm.InventoryPath = element.Path
+	}
+
+	return vm
+}
+
+// extraConfigValue returns the string value of the extraConfig key of the
+// virtual machine, or an empty string when the key is not present.
+func extraConfigValue(props *mo.VirtualMachine, key string) string {
+	if props.Config == nil {
+		return ""
+	}
+
+	for _, v := range props.Config.ExtraConfig {
+		ov := v.GetOptionValue()
+		if ov.Key == key {
+			if value, ok := ov.Value.(string); ok {
+				return value
+			}
+		}
+	}
+
+	return ""
+}
+
 // Properties is a convenience method that wraps fetching the
 // VirtualMachine MO from its higher-level object.
-func Properties(vm *object.VirtualMachine) (*mo.VirtualMachine, error) {
+func Properties(ctx context.Context, vimClient *VimClient, vm *object.VirtualMachine) (*mo.VirtualMachine, error) {
 	log.Printf("[DEBUG] Fetching properties for VM %q", vm.InventoryPath)
-	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	ctx, cancel := context.WithTimeout(ctx, vimClient.timeout)
 	defer cancel()
 	var props mo.VirtualMachine
 	if err := vm.Properties(ctx, vm.Reference(), nil, &props); err != nil {
@@ -21,6 +21,17 @@ func (at *AccTests) TestAccVcdaDataSourceServiceCert_basic(t *testing.T) {
 				Config: testAccVcdaServiceCertConfigBasic(),
 				Check: resource.ComposeTestCheckFunc(
 					resource.TestCheckResourceAttrSet("data.vcda_service_cert.service_cert", "id"),
+					resource.TestCheckResourceAttr("data.vcda_service_cert.service_cert", "detected_type", "cloud"),
+					resource.TestCheckResourceAttrSet("data.vcda_service_cert.service_cert", "thumbprint"),
+				),
+			},
+			{
+				Config: testAccVcdaServiceCertConfigAuto(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.vcda_service_cert.by_ip", "id",
+						"data.vcda_service_cert.service_cert", "id"),
+					resource.TestCheckResourceAttr("data.vcda_service_cert.by_ip", "detected_type", "cloud"),
+					resource.TestCheckResourceAttr("data.vcda_service_cert.by_ip", "power_state", "poweredOn"),
 				),
 			},
 		},
@@ -38,3 +49,19 @@ data "vcda_service_cert" "service_cert" {
 		os.Getenv(CloudVMName),
 	)
 }
+
+func testAccVcdaServiceCertConfigAuto() string {
+	return fmt.Sprintf(`
+data "vcda_service_cert" "service_cert" {
+  datacenter_id = %q
+  name          = %q
+  type          = "cloud"
+}
+
+data "vcda_service_cert" "by_ip" {
+  ip_address = data.vcda_service_cert.service_cert.vm_ip_address
+}`,
+		os.Getenv(DatacenterID),
+		os.Getenv(CloudVMName),
+	)
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// The connectivity statuses of a tunnel.
+const (
+	tunnelConnected    = "CONNECTED"
+	tunnelDisconnected = "DISCONNECTED"
+	tunnelUnknown      = "UNKNOWN"
+)
+
+func dataSourceVcdaTunnels() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceVcdaTunnelsRead,
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"service_cert": {
+				Type: schema.TypeString,
+				Description: "The certificate of the Cloud Director Replication Manager Service. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
+			},
+			// Computed
+			"id": {
+				Type:        schema.TypeString,
+				Description: "The health info task ID of the Cloud Director Replication Manager Service.",
+				Computed:    true,
+			},
+			"tunnels": {
+				Type:        schema.TypeList,
+				Description: "The tunnels of the Cloud Director Replication Manager Service, in order of priority.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeString,
+							Description: "The ID of the Tunnel Service.",
+							Computed:    true,
+						},
+						"url": {
+							Type:        schema.TypeString,
+							Description: "The URL of the Tunnel Service.",
+							Computed:    true,
+						},
+						"certificate": {
+							Type:        schema.TypeString,
+							Description: "The certificate of the Tunnel Service.",
+							Computed:    true,
+						},
+						"priority": {
+							Type:        schema.TypeInt,
+							Description: "The priority of the Tunnel Service. The tunnels with lower values are preferred.",
+							Computed:    true,
+						},
+						"connectivity_status": {
+							Type: schema.TypeString,
+							Description: "The connectivity status of the Tunnel Service, one of `" + tunnelConnected + "`, `" +
+								tunnelDisconnected + "` or `" + tunnelUnknown + "`.",
+							Computed: true,
+						},
+						"error_code": {
+							Type:        schema.TypeString,
+							Description: "The connectivity error code.",
+							Computed:    true,
+						},
+						"error_msg": {
+							Type:        schema.TypeString,
+							Description: "The connectivity error message.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceVcdaTunnelsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*Client)
+
+	serviceCert := d.Get("service_cert").(string)
+
+	tunnels, err := c.getTunnels(ctx, serviceCert)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	taskID, err := c.getCloudHealth(ctx, serviceCert)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(*taskID)
+
+	if err := retryHealthTask(ctx, c, d, serviceCert, taskID); err != nil {
+		return diag.FromErr(err)
+	}
+
+	health, err := getHealthTaskResult(ctx, c, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	tunnelConnectivity, _ := health["tunnelConnectivity"].([]interface{})
+
+	if err := setTunnelsData(d, tunnels, tunnelConnectivity); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diags
+}
+
+func setTunnelsData(d *schema.ResourceData, tunnels []TunnelConfig, tunnelConnectivity []interface{}) error {
+	sort.SliceStable(tunnels, func(i, j int) bool {
+		return tunnels[i].Priority < tunnels[j].Priority
+	})
+
+	tunnelsData := make([]interface{}, 0, len(tunnels))
+	for _, tunnel := range tunnels {
+		tunnelData := map[string]interface{}{
+			"id":                  tunnel.ID,
+			"url":                 tunnel.URL,
+			"certificate":         tunnel.Certificate,
+			"priority":            tunnel.Priority,
+			"connectivity_status": tunnelUnknown,
+		}
+
+		if connectivity, err := findTunnel(tunnel.ID, tunnelConnectivity); err == nil {
+			tunnelData["connectivity_status"] = tunnelConnected
+			if tunError, ok := connectivity["error"].(map[string]interface{}); ok {
+				tunnelData["connectivity_status"] = tunnelDisconnected
+				tunnelData["error_code"] = fmt.Sprint(tunError["code"])
+				tunnelData["error_msg"] = fmt.Sprint(tunError["msg"])
+			}
+		}
+
+		tunnelsData = append(tunnelsData, tunnelData)
+	}
+
+	if err := d.Set("tunnels", tunnelsData); err != nil {
+		return fmt.Errorf("error setting tunnels field: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"os"
+	"testing"
+)
+
+func (at *AccTests) TestAccVcdaDataSourceTunnels_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testAccVcdaCloudHealthPreCheck(t)
+		},
+		ProviderFactories: testProviders(),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVcdaTunnelsConfigBasic(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.vcda_tunnels.tunnels", "tunnels.0.id"),
+					resource.TestCheckResourceAttrSet("data.vcda_tunnels.tunnels", "tunnels.0.url"),
+					resource.TestCheckResourceAttrSet("data.vcda_tunnels.tunnels", "tunnels.0.certificate"),
+					resource.TestCheckResourceAttrSet("data.vcda_tunnels.tunnels", "tunnels.0.priority"),
+					resource.TestCheckResourceAttr("data.vcda_tunnels.tunnels", "tunnels.0.connectivity_status", tunnelConnected),
+				),
+			},
+		},
+	})
+}
+
+func testAccVcdaTunnelsConfigBasic() string {
+	return fmt.Sprintf(`
+data "vcda_service_cert" "cloud_service_cert" {
+  datacenter_id = %q
+  name          = %q
+  type          = "cloud"
+}
+
+data "vcda_tunnels" "tunnels" {
+  service_cert = data.vcda_service_cert.cloud_service_cert.id
+}
+`,
+		os.Getenv(DatacenterID),
+		os.Getenv(CloudVMName),
+	)
+}
+
+// TestVcdaDataSourceTunnels_setTunnelsData orders the tunnels by priority and
+// reports their connectivity.
+func (at *AccTests) TestVcdaDataSourceTunnels_setTunnelsData(t *testing.T) {
+	d := dataSourceVcdaTunnels().TestResourceData()
+	tunnels := []TunnelConfig{
+		{ID: "tunnel-2", URL: "https://tunnel-2:8048", Priority: 2},
+		{ID: "tunnel-1", URL: "https://tunnel-1:8048", Priority: 1},
+		{ID: "tunnel-3", URL: "https://tunnel-3:8048", Priority: 3},
+	}
+	connectivity := []interface{}{
+		map[string]interface{}{"tunnelService": map[string]interface{}{"id": "tunnel-1"}},
+		map[string]interface{}{
+			"tunnelService": map[string]interface{}{"id": "tunnel-2"},
+			"error":         map[string]interface{}{"code": "TunnelUnreachable", "msg": "unreachable"},
+		},
+	}
+
+	if err := setTunnelsData(d, tunnels, connectivity); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for i, want := range []struct{ id, status string }{
+		{"tunnel-1", tunnelConnected},
+		{"tunnel-2", tunnelDisconnected},
+		{"tunnel-3", tunnelUnknown},
+	} {
+		if got := d.Get(fmt.Sprintf("tunnels.%d.id", i)); got != want.id {
+			t.Errorf("tunnel %d: expected %s, got %s", i, want.id, got)
+		}
+		if got := d.Get(fmt.Sprintf("tunnels.%d.connectivity_status", i)); got != want.status {
+			t.Errorf("tunnel %s: expected status %s, got %s", want.id, want.status, got)
+		}
+	}
+	if got := d.Get("tunnels.1.error_code"); got != "TunnelUnreachable" {
+		t.Errorf("unexpected error code: %s", got)
+	}
+}
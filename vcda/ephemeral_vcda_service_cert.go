@@ -0,0 +1,158 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ ephemeral.EphemeralResourceWithConfigure = &ephemeralVcdaServiceCert{}
+var _ ephemeral.EphemeralResourceWithValidateConfig = &ephemeralVcdaServiceCert{}
+
+type ephemeralVcdaServiceCert struct {
+	providerData interface{}
+}
+
+type ephemeralVcdaServiceCertModel struct {
+	DatacenterID types.String `tfsdk:"datacenter_id"`
+	Name         types.String `tfsdk:"name"`
+	InstanceUUID types.String `tfsdk:"instance_uuid"`
+	BiosUUID     types.String `tfsdk:"bios_uuid"`
+	MOID         types.String `tfsdk:"moid"`
+	IPAddress    types.String `tfsdk:"ip_address"`
+	Type         types.String `tfsdk:"type"`
+	Certificate  types.String `tfsdk:"certificate"`
+	DetectedType types.String `tfsdk:"detected_type"`
+	Thumbprint   types.String `tfsdk:"thumbprint"`
+}
+
+func newEphemeralVcdaServiceCert() ephemeral.EphemeralResource {
+	return &ephemeralVcdaServiceCert{}
+}
+
+func (e *ephemeralVcdaServiceCert) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_service_cert"
+}
+
+func (e *ephemeralVcdaServiceCert) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "The service certificate of an appliance, read from the VM's guest info extraConfig property " +
+			"without storing it in the Terraform state.",
+		Attributes: map[string]schema.Attribute{
+			"datacenter_id": schema.StringAttribute{
+				Description: "The managed object ID of the datacenter where the virtual machine resides in.",
+				Optional:    true,
+			},
+			"name": schema.StringAttribute{
+				Description: "The VM name or inventory path of the appliance.",
+				Optional:    true,
+			},
+			"instance_uuid": schema.StringAttribute{
+				Description: "The instance UUID of the appliance VM.",
+				Optional:    true,
+			},
+			"bios_uuid": schema.StringAttribute{
+				Description: "The BIOS UUID of the appliance VM.",
+				Optional:    true,
+			},
+			"moid": schema.StringAttribute{
+				Description: "The managed object ID of the appliance VM.",
+				Optional:    true,
+			},
+			"ip_address": schema.StringAttribute{
+				Description: "The guest IP address of the appliance VM, as reported by VMware Tools.",
+				Optional:    true,
+			},
+			"type": schema.StringAttribute{
+				Description: "The type of the appliance role: manager, cloud, tunnel, replicator or auto. Defaults to auto.",
+				Optional:    true,
+			},
+			"certificate": schema.StringAttribute{
+				Description: "The certificate in a base64-encoded DER format, that is no PEM header nor footer and no new lines.",
+				Computed:    true,
+			},
+			"detected_type": schema.StringAttribute{
+				Description: "The appliance role whose certificate was returned.",
+				Computed:    true,
+			},
+			"thumbprint": schema.StringAttribute{
+				Description: "The SHA-256 thumbprint of the certificate, prefixed with `SHA-256:`.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (e *ephemeralVcdaServiceCert) ValidateConfig(ctx context.Context, req ephemeral.ValidateConfigRequest, resp *ephemeral.ValidateConfigResponse) {
+	var data ephemeralVcdaServiceCertModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	set := 0
+	for _, v := range []types.String{data.Name, data.InstanceUUID, data.BiosUUID, data.MOID, data.IPAddress} {
+		if !v.IsNull() {
+			set++
+		}
+	}
+	if set != 1 {
+		resp.Diagnostics.AddError("Invalid VM lookup",
+			"Exactly one of name, instance_uuid, bios_uuid, moid or ip_address must be given.")
+	}
+
+	if !data.Type.IsNull() && !data.Type.IsUnknown() {
+		switch data.Type.ValueString() {
+		case "manager", "cloud", "tunnel", "replicator", "auto":
+		default:
+			resp.Diagnostics.AddAttributeError(path.Root("type"), "Invalid appliance type",
+				"type must be one of manager, cloud, tunnel, replicator or auto.")
+		}
+	}
+}
+
+func (e *ephemeralVcdaServiceCert) Configure(_ context.Context, req ephemeral.ConfigureRequest, _ *ephemeral.ConfigureResponse) {
+	e.providerData = req.ProviderData
+}
+
+func (e *ephemeralVcdaServiceCert) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data ephemeralVcdaServiceCertModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c, err := clientFromProviderData(e.providerData)
+	if err != nil {
+		resp.Diagnostics.AddError("Could not read the service certificate", err.Error())
+		return
+	}
+
+	cert, err := lookupServiceCert(ctx, c, ServiceCertLookup{
+		DatacenterID: data.DatacenterID.ValueString(),
+		Name:         data.Name.ValueString(),
+		InstanceUUID: data.InstanceUUID.ValueString(),
+		BiosUUID:     data.BiosUUID.ValueString(),
+		MOID:         data.MOID.ValueString(),
+		IPAddress:    data.IPAddress.ValueString(),
+		Type:         data.Type.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Could not read the service certificate", err.Error())
+		return
+	}
+
+	data.Certificate = types.StringValue(cert.Certificate)
+	data.DetectedType = types.StringValue(cert.DetectedType)
+	data.Thumbprint = types.StringValue(cert.Thumbprint)
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
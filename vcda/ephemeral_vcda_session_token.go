@@ -0,0 +1,138 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ ephemeral.EphemeralResourceWithConfigure = &ephemeralVcdaSessionToken{}
+var _ ephemeral.EphemeralResourceWithClose = &ephemeralVcdaSessionToken{}
+
+// sessionTokenPrivateKey is the private data key of the session to log out.
+const sessionTokenPrivateKey = "session"
+
+type ephemeralVcdaSessionToken struct {
+	providerData interface{}
+}
+
+type ephemeralVcdaSessionTokenModel struct {
+	Address     types.String `tfsdk:"address"`
+	ServiceCert types.String `tfsdk:"service_cert"`
+	Token       types.String `tfsdk:"token"`
+}
+
+// sessionTokenPrivate holds what is needed to log out the session on close.
+type sessionTokenPrivate struct {
+	Host        string `json:"host"`
+	ServiceCert string `json:"serviceCert"`
+	Token       string `json:"token"`
+}
+
+func newEphemeralVcdaSessionToken() ephemeral.EphemeralResource {
+	return &ephemeralVcdaSessionToken{}
+}
+
+func (e *ephemeralVcdaSessionToken) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_session_token"
+}
+
+func (e *ephemeralVcdaSessionToken) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "An appliance session token, opened with the provider credentials and logged out when no longer needed.",
+		Attributes: map[string]schema.Attribute{
+			"address": schema.StringAttribute{
+				Description: "The address of the appliance, optionally with a port. Defaults to the provider `vcda_ip`.",
+				Optional:    true,
+			},
+			"service_cert": schema.StringAttribute{
+				Description: "The certificate of the appliance service. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
+			},
+			"token": schema.StringAttribute{
+				Description: "The session token, sent in the `X-VCAV-Auth` header of the API requests.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func (e *ephemeralVcdaSessionToken) Configure(_ context.Context, req ephemeral.ConfigureRequest, _ *ephemeral.ConfigureResponse) {
+	e.providerData = req.ProviderData
+}
+
+func (e *ephemeralVcdaSessionToken) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data ephemeralVcdaSessionTokenModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c, err := clientFromProviderData(e.providerData)
+	if err != nil {
+		resp.Diagnostics.AddError("Could not open the session", err.Error())
+		return
+	}
+
+	host := data.Address.ValueString()
+	if host == "" {
+		host = c.VcdaIP
+	}
+	serviceCert := data.ServiceCert.ValueString()
+
+	localPassword, err := c.localPasswordFor(host)
+	if err != nil {
+		resp.Diagnostics.AddError("Could not open the session", err.Error())
+		return
+	}
+
+	token, err := c.GetAuthToken(ctx, host, localPassword, serviceCert)
+	if err != nil {
+		resp.Diagnostics.AddError("Could not open the session", err.Error())
+		return
+	}
+
+	private, err := json.Marshal(sessionTokenPrivate{Host: host, ServiceCert: serviceCert, Token: *token})
+	if err != nil {
+		resp.Diagnostics.AddError("Could not store the session", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, sessionTokenPrivateKey, private)...)
+
+	data.Token = types.StringValue(*token)
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
+
+func (e *ephemeralVcdaSessionToken) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	privateData, diags := req.Private.GetKey(ctx, sessionTokenPrivateKey)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || privateData == nil {
+		return
+	}
+
+	var session sessionTokenPrivate
+	if err := json.Unmarshal(privateData, &session); err != nil {
+		resp.Diagnostics.AddError("Could not read the session", err.Error())
+		return
+	}
+
+	c, err := clientFromProviderData(e.providerData)
+	if err != nil {
+		resp.Diagnostics.AddError("Could not log out the session", err.Error())
+		return
+	}
+
+	if err := c.DeleteSession(ctx, session.Host, session.Token, session.ServiceCert); err != nil {
+		resp.Diagnostics.AddError("Could not log out the session", err.Error())
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"testing"
+)
+
+func (at *AccTests) TestAccVcdaEphemeralSessionToken_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVcdaEphemeralSessionTokenConfigBasic(),
+			},
+		},
+	})
+}
+
+func testAccVcdaEphemeralSessionTokenConfigBasic() string {
+	return `
+ephemeral "vcda_session_token" "token" {}
+`
+}
@@ -5,10 +5,16 @@
 
 package vcda
 
+import "encoding/json"
+
 type AuthTokenData struct {
 	Type          string `json:"type"`
-	LocalUser     string `json:"localUser"`
-	LocalPassword string `json:"localPassword"`
+	LocalUser     string `json:"localUser,omitempty"`
+	LocalPassword string `json:"localPassword,omitempty"`
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	VcdUser       string `json:"vcdUser,omitempty"`
+	VcdPassword   string `json:"vcdPassword,omitempty"`
 }
 
 type PasswordData struct {
@@ -97,18 +103,19 @@ type LookupService struct {
 }
 
 type Replicator struct {
-	ID                  string      `json:"id"`
-	Owner               string      `json:"owner"`
-	Site                string      `json:"site"`
-	Description         string      `json:"description"`
-	APIURL              string      `json:"apiUrl"`
-	CERTThumbprint      string      `json:"certThumbprint"`
-	PairingCookie       interface{} `json:"pairingCookie"`
-	State               State       `json:"state"`
-	IsInMaintenanceMode bool        `json:"isInMaintenanceMode"`
-	APIVersion          string      `json:"apiVersion"`
-	DataAddress         interface{} `json:"dataAddress"`
-	BuildVersion        interface{} `json:"buildVersion"`
+	ID                  string          `json:"id"`
+	Owner               string          `json:"owner"`
+	Site                string          `json:"site"`
+	Description         string          `json:"description"`
+	APIURL              string          `json:"apiUrl"`
+	CERTThumbprint      string          `json:"certThumbprint"`
+	PairingCookie       interface{}     `json:"pairingCookie"`
+	State               State           `json:"state"`
+	IsInMaintenanceMode bool            `json:"isInMaintenanceMode"`
+	ReplicationsCount   int             `json:"replicationsCount"`
+	APIVersion          string          `json:"apiVersion"`
+	DataAddress         json.RawMessage `json:"dataAddress"`
+	BuildVersion        interface{}     `json:"buildVersion"`
 }
 
 type State struct {
@@ -145,6 +152,28 @@ type ReplicatorLookupServiceData struct {
 	RootPassword  string `json:"rootPassword"`
 }
 
+type ReplicatorDataAddressData struct {
+	APIURL        string `json:"apiUrl"`
+	APIThumbprint string `json:"apiThumbprint"`
+	RootPassword  string `json:"rootPassword"`
+	Address       string `json:"address"`
+	Port          int    `json:"port"`
+}
+
+type TrafficSettings struct {
+	IncomingLimitMbps int               `json:"incomingLimitMbps"`
+	OutgoingLimitMbps int               `json:"outgoingLimitMbps"`
+	Schedules         []TrafficSchedule `json:"schedules"`
+}
+
+type TrafficSchedule struct {
+	Days              []string `json:"days"`
+	StartTime         string   `json:"startTime"`
+	EndTime           string   `json:"endTime"`
+	IncomingLimitMbps int      `json:"incomingLimitMbps"`
+	OutgoingLimitMbps int      `json:"outgoingLimitMbps"`
+}
+
 type ReplicatorConfigData struct {
 	APIURL        string `json:"apiUrl"`
 	APIThumbprint string `json:"apiThumbprint"`
@@ -165,6 +194,7 @@ type TunnelData struct {
 	Certificate  string `json:"certificate"`
 	RootPassword string `json:"rootPassword"`
 	URL          string `json:"url"`
+	Priority     *int   `json:"priority,omitempty"`
 }
 
 type Tunnels struct {
@@ -175,6 +205,7 @@ type TunnelConfig struct {
 	ID          string `json:"id"`
 	URL         string `json:"url"`
 	Certificate string `json:"certificate"`
+	Priority    int    `json:"priority"`
 }
 
 type VspherePluginStatus struct {
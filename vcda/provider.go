@@ -7,61 +7,250 @@ package vcda
 
 import (
 	"context"
+	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"slices"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 // defaultAPITimeout is a default timeout value that is passed to functions
 // requiring contexts, and other various waiters.
 var defaultAPITimeout = time.Minute * 5
 
+// defaultVimSessionPath is the directory of the persisted vSphere sessions,
+// shared with govc.
+const defaultVimSessionPath = "~/.govmomi/sessions"
+
 func Provider() *schema.Provider {
-	return &schema.Provider{
+	p := &schema.Provider{
 		Schema: map[string]*schema.Schema{
+			"profile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(Profile, nil),
+				Description: "The name of the profile of the config file to read the provider settings from. " +
+					"The provider arguments and their environment variables take precedence over the profile.",
+			},
+			"config_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(ConfigFile, nil),
+				Description: "The name of the config file with the provider profiles. Defaults to `" + defaultConfigFile + "`.",
+			},
 			"vcda_ip": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc(VcdaIP, nil),
 				Description: "The IP address of either the Cloud Director Replication Management Appliance or " +
-					"the vCenter Replication Management Appliance.",
+					"the vCenter Replication Management Appliance. Required, unless set in the profile.",
 			},
 			"local_user": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc(LocalUser, nil),
-				Description: "The local user of the appliance.",
+				Description: "The local user of the appliance. Required with the `local` authentication type.",
 			},
 			"local_password": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc(LocalPassword, nil),
-				Description: "The local password of the appliance.",
+				Description: "The local password of the appliance. Required with the `local` authentication type.",
+			},
+			"local_password_command": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "A command that prints the local password of the appliance to stdout, such as a password " +
+					"manager client. Takes precedence over the `LOCAL_PASSWORD` environment variable.",
+				ConflictsWith: []string{"local_password"},
+			},
+			"auth_type": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(AuthType, nil),
+				Description: "The authentication type of the appliance sessions: `local` for the local user, " +
+					"`sso` for a vSphere SSO user of a vCenter Replication Manager or `vcd` for Cloud Director provider " +
+					"credentials of a Cloud Director Replication Manager.",
+				ValidateFunc: validation.StringInSlice(authTypes, false),
+			},
+			"auth_user": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(AuthUser, nil),
+				Description: "The vSphere SSO user or the Cloud Director provider user, for example `administrator@system`. " +
+					"Required with the `sso` and `vcd` authentication types.",
+			},
+			"auth_password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc(AuthPassword, nil),
+				Description: "The password of `auth_user`.",
+			},
+			"auth_password_command": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "A command that prints the password of `auth_user` to stdout. " +
+					"Takes precedence over the `" + AuthPassword + "` environment variable.",
+				ConflictsWith: []string{"auth_password"},
 			},
 			"vsphere_user": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc(VsphereUser, nil),
-				Description: "The user name for performing vSphere API operations.",
+				Description: "The user name for performing vSphere API operations. Required by the features that read the appliance VMs.",
 			},
 			"vsphere_password": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc(VspherePassword, nil),
-				Description: "The password of the user for performing vSphere API operations.",
+				Description: "The password of the user for performing vSphere API operations. Required by the features that read the appliance VMs.",
+			},
+			"vsphere_password_command": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "A command that prints the password of the vSphere user to stdout. " +
+					"Takes precedence over the `VSPHERE_PASSWORD` environment variable.",
+				ConflictsWith: []string{"vsphere_password"},
 			},
 			"vsphere_server": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc(VsphereServer, nil),
-				Description: "The vSphere server name for performing vSphere API operations.",
+				Description: "The vSphere server name for performing vSphere API operations. Required by the features that read the appliance VMs.",
 			},
 			"vsphere_allow_unverified_ssl": {
 				Type:        schema.TypeBool,
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc(VsphereAllowUnverifiedSSL, true),
-				Description: "When set, the vSphere client establishes an insecure TLS connection without performing certificate validations.",
+				Description: "When set, the vSphere client establishes an insecure TLS connection without performing certificate validations. " +
+					"Ignored when `ca_bundle` or `ca_bundle_file` is set.",
+			},
+			"vim_keep_alive": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc(VsphereVimKeepAlive, 10),
+				Description:  "The keep alive interval of the vSphere session, in minutes.",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"persist_session": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(VspherePersistSession, false),
+				Description: "When set, the vSphere session is saved to disk and reused by the next runs, " +
+					"instead of logging in to vSphere on every run.",
+			},
+			"vim_session_path": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(VsphereVimSessionPath, nil),
+				Description: "The directory of the persisted vSphere sessions, which must not be accessible by other users. " +
+					"Defaults to `" + defaultVimSessionPath + "`.",
+			},
+			"client_debug": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(VsphereClientDebug, false),
+				Description: "When set, the vSphere API calls are logged to `client_debug_path`.",
+			},
+			"client_debug_path": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(VsphereClientDebugPath, nil),
+				Description: "The directory of the vSphere API call logs. Defaults to `~/.govmomi`.",
+			},
+			"api_timeout": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(APITimeout, nil),
+				Description: "The timeout of the vSphere session setup and inventory operations and of the " +
+					"certificate fetches from the appliances, as a duration such as `10m`. Defaults to `5m`.",
+				ValidateFunc: validateDuration,
+			},
+			"ca_bundle": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(CABundle, nil),
+				Description: "PEM encoded CA certificates trusted for the vSphere server and the appliances, " +
+					"in addition to the appliance `service_cert`.",
+			},
+			"ca_bundle_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(CABundleFile, nil),
+				Description: "The name of a file with PEM encoded CA certificates trusted for the vSphere server and the appliances, " +
+					"in addition to the appliance `service_cert`.",
+			},
+			"vcda_certificate_pinning": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(CertificatePinning, false),
+				Description: "When set, an appliance connection is trusted only when the appliance presents exactly its `service_cert` " +
+					"certificate, without verifying the host name. Use when the appliances are addressed by IP addresses " +
+					"that are not part of their certificates.",
+			},
+			"appliance": {
+				Type: schema.TypeList,
+				Description: "Per-appliance settings, matched by the appliance address. " +
+					"They are used to discover the appliance certificate when `service_cert` is not set.",
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"address": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The IP address or host name the appliance is reached at.",
+						},
+						"vm_name": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Description: "The VM name or inventory path of the appliance. " +
+								"When not set, the appliance VM is looked up by `address`.",
+						},
+						"datacenter_id": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The managed object ID of the datacenter where the appliance VM resides in.",
+						},
+						"thumbprint": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Description: "The SHA-256 thumbprint of the appliance certificate. When the certificate cannot be " +
+								"discovered in vSphere, it is fetched from the appliance and trusted only if it matches this thumbprint.",
+						},
+						"auth_type": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Description:  "The authentication type of the appliance sessions. Overrides the provider `auth_type`.",
+							ValidateFunc: validation.StringInSlice(authTypes, false),
+						},
+						"auth_user": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The user for the appliance sessions. Overrides the provider `auth_user`.",
+						},
+						"auth_password": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Sensitive:   true,
+							Description: "The password of the appliance `auth_user`. Overrides the provider `auth_password`.",
+						},
+						"auth_password_command": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Description: "A command that prints the password of the appliance `auth_user` to stdout. " +
+								"Overrides the provider `auth_password` and `auth_password_command`.",
+						},
+						"local_password_command": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Description: "A command that prints the local password of the appliance to stdout. " +
+								"Overrides the provider `local_password` and `local_password_command`.",
+						},
+					},
+				},
 			},
 		},
 
@@ -70,39 +259,143 @@ func Provider() *schema.Provider {
 			"vcda_vcenter_replication_manager":        resourceVcdaVcenterReplicationManager(),
 			"vcda_cloud_director_replication_manager": resourceVcdaCloudDirectorReplicationManager(),
 			"vcda_replicator":                         resourceVcdaReplicator(),
+			"vcda_replicator_pool":                    resourceVcdaReplicatorPool(),
 			"vcda_tunnel":                             resourceVcdaTunnel(),
 			"vcda_pair_site":                          resourceVcdaPairSite(),
+			"vcda_traffic_settings":                   resourceVcdaTrafficSettings(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
 			"vcda_remote_services_thumbprint": dataSourceVcdaRemoteServicesThumbprint(),
 			"vcda_service_cert":               dataSourceVcdaServiceCert(),
+			"vcda_appliances":                 dataSourceVcdaAppliances(),
 			"vcda_cloud_health":               dataSourceVcdaCloudHealth(),
 			"vcda_manager_health":             dataSourceVcdaManagerHealth(),
 			"vcda_replicator_health":          dataSourceVcdaReplicatorHealth(),
 			"vcda_tunnel_connectivity":        dataSourceVcdaTunnelConnectivity(),
+			"vcda_tunnels":                    dataSourceVcdaTunnels(),
 		},
 		ConfigureContextFunc: providerConfigure,
 	}
+
+	traceResources(p.ResourcesMap)
+	traceResources(p.DataSourcesMap)
+
+	return p
 }
 
 func providerConfigure(_ context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-	vcdaIP := d.Get("vcda_ip").(string)
+	settings, err := newProviderSettings(d)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	if err := settings.checkRequired(); err != nil {
+		return nil, diag.FromErr(err)
+	}
 
-	localUser := d.Get("local_user").(string)
-	localPassword := d.Get("local_password").(string)
-	if len(localPassword) <= 0 {
+	client := &Client{
+		VcdaIP:     settings.String("vcda_ip"),
+		LocalUser:  settings.String("local_user"),
+		Appliances: expandApplianceConfigs(d.Get("appliance").([]interface{})),
+	}
+
+	localPassword, err := client.commandOrSecret(settings.String("local_password_command"), settings.String("local_password"))
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	client.LocalPassword = localPassword
+
+	client.Auth = AuthConfig{
+		Type:     settings.String("auth_type"),
+		User:     settings.String("auth_user"),
+		Password: settings.String("auth_password"),
+	}
+	if command := settings.String("auth_password_command"); command != "" {
+		client.Auth.Password = ""
+		client.Auth.PasswordCommand = command
+	}
+	if client.Auth.Type == "" {
+		client.Auth.Type = AuthTypeLocal
+	}
+	if !slices.Contains(authTypes, client.Auth.Type) {
+		return nil, diag.Errorf("unsupported auth_type %q", client.Auth.Type)
+	}
+
+	if client.Auth.Type == AuthTypeLocal && len(localPassword) <= 0 {
 		return nil, diag.Errorf("local_password cannot be empty")
 	}
+	for _, appliance := range client.Appliances {
+		if appliance.Auth.Type == AuthTypeLocal && appliance.LocalPasswordCommand == "" && len(localPassword) <= 0 {
+			return nil, diag.Errorf("local_password cannot be empty, it is required by appliance %s", appliance.Address)
+		}
+	}
 
-	c, err := NewConfig(d)
+	caBundleFile, err := expandHome(settings.String("ca_bundle_file"))
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
-	vimClient, err := c.VimClient()
+	client.CACertPool, err = loadCABundle(settings.String("ca_bundle"), caBundleFile)
 	if err != nil {
-		return nil, diag.Errorf("could not initialize vim client: %s", err)
+		return nil, diag.FromErr(err)
+	}
+
+	client.PinServiceCert, err = settings.Bool("vcda_certificate_pinning", CertificatePinning)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	client.APITimeout, err = settings.Duration("api_timeout", defaultAPITimeout)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	if settings.vSphereConfigured() {
+		c, err := NewConfig(settings)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		c.RootCAs = client.CACertPool
+		c.PasswordCommand = settings.String("vsphere_password_command")
+		client.vimConfig = c
+	}
+
+	return client, nil
+}
+
+func validateDuration(v interface{}, k string) (ws []string, errors []error) {
+	duration, err := time.ParseDuration(v.(string))
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s must be a duration such as 10m: %s", k, err)}
+	}
+	if duration <= 0 {
+		return nil, []error{fmt.Errorf("%s must be positive", k)}
+	}
+
+	return nil, nil
+}
+
+func expandApplianceConfigs(appliances []interface{}) map[string]ApplianceConfig {
+	configs := make(map[string]ApplianceConfig, len(appliances))
+	for _, v := range appliances {
+		appliance, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		config := ApplianceConfig{
+			Address:      appliance["address"].(string),
+			VMName:       appliance["vm_name"].(string),
+			DatacenterID: appliance["datacenter_id"].(string),
+			Thumbprint:   appliance["thumbprint"].(string),
+			Auth: AuthConfig{
+				Type:            appliance["auth_type"].(string),
+				User:            appliance["auth_user"].(string),
+				Password:        appliance["auth_password"].(string),
+				PasswordCommand: appliance["auth_password_command"].(string),
+			},
+			LocalPasswordCommand: appliance["local_password_command"].(string),
+		}
+		configs[config.Address] = config
 	}
-	client := Client{*vimClient, vcdaIP, localUser, localPassword}
 
-	return &client, nil
+	return configs
 }
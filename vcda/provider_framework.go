@@ -0,0 +1,235 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	providerschema "github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// ProviderServerFactory returns the provider server that combines the SDK
+// provider with the framework provider of the ephemeral resources.
+func ProviderServerFactory(ctx context.Context) (func() tfprotov5.ProviderServer, error) {
+	sdkProvider := Provider()
+
+	providers := []func() tfprotov5.ProviderServer{
+		sdkProvider.GRPCProvider,
+		providerserver.NewProtocol5(newFrameworkProvider(sdkProvider)),
+	}
+
+	muxServer, err := tf5muxserver.NewMuxServer(ctx, providers...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create provider server: %s", err)
+	}
+
+	return muxServer.ProviderServer, nil
+}
+
+// frameworkProvider serves the ephemeral resources, which the SDK does not
+// support. It shares the provider configuration and the client of the SDK
+// provider.
+type frameworkProvider struct {
+	sdkProvider *schema.Provider
+}
+
+var _ provider.ProviderWithEphemeralResources = &frameworkProvider{}
+
+func newFrameworkProvider(sdkProvider *schema.Provider) provider.Provider {
+	return &frameworkProvider{sdkProvider: sdkProvider}
+}
+
+func (p *frameworkProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "vcda"
+}
+
+// Schema returns the schema of the SDK provider, since the combined
+// provider requires identical provider schemas.
+func (p *frameworkProvider) Schema(ctx context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
+	sdkSchema, err := p.sdkProvider.GRPCProvider().GetProviderSchema(ctx, &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		resp.Diagnostics.AddError("Could not read the provider schema", err.Error())
+		return
+	}
+
+	attributes, blocks, err := frameworkProviderSchema(sdkSchema.Provider.Block)
+	if err != nil {
+		resp.Diagnostics.AddError("Could not convert the provider schema", err.Error())
+		return
+	}
+
+	resp.Schema = providerschema.Schema{
+		Attributes: attributes,
+		Blocks:     blocks,
+	}
+}
+
+// Configure hands the SDK provider to the ephemeral resources. Its client
+// is read when an ephemeral resource is opened, after the SDK provider has
+// been configured.
+func (p *frameworkProvider) Configure(_ context.Context, _ provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	resp.EphemeralResourceData = p.sdkProvider
+}
+
+func (p *frameworkProvider) Resources(_ context.Context) []func() resource.Resource {
+	return nil
+}
+
+func (p *frameworkProvider) DataSources(_ context.Context) []func() datasource.DataSource {
+	return nil
+}
+
+func (p *frameworkProvider) EphemeralResources(_ context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		newEphemeralVcdaSessionToken,
+		newEphemeralVcdaServiceCert,
+	}
+}
+
+// clientFromProviderData returns the client of the configured SDK provider.
+func clientFromProviderData(providerData interface{}) (*Client, error) {
+	sdkProvider, ok := providerData.(*schema.Provider)
+	if !ok {
+		return nil, fmt.Errorf("unexpected provider data type: %T", providerData)
+	}
+
+	c, ok := sdkProvider.Meta().(*Client)
+	if !ok {
+		return nil, fmt.Errorf("the provider is not configured")
+	}
+
+	return c, nil
+}
+
+// frameworkProviderSchema converts the protocol schema of the SDK provider to
+// framework provider schema attributes and blocks.
+func frameworkProviderSchema(block *tfprotov5.SchemaBlock) (map[string]providerschema.Attribute, map[string]providerschema.Block, error) {
+	attributes := make(map[string]providerschema.Attribute, len(block.Attributes))
+	for _, a := range block.Attributes {
+		attribute, err := frameworkProviderAttribute(a)
+		if err != nil {
+			return nil, nil, err
+		}
+		attributes[a.Name] = attribute
+	}
+
+	blocks := make(map[string]providerschema.Block, len(block.BlockTypes))
+	for _, b := range block.BlockTypes {
+		nestedAttributes, nestedBlocks, err := frameworkProviderSchema(b.Block)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		object := providerschema.NestedBlockObject{
+			Attributes: nestedAttributes,
+			Blocks:     nestedBlocks,
+		}
+
+		switch b.Nesting {
+		case tfprotov5.SchemaNestedBlockNestingModeList:
+			blocks[b.TypeName] = providerschema.ListNestedBlock{
+				NestedObject: object,
+				Description:  b.Block.Description,
+			}
+		case tfprotov5.SchemaNestedBlockNestingModeSet:
+			blocks[b.TypeName] = providerschema.SetNestedBlock{
+				NestedObject: object,
+				Description:  b.Block.Description,
+			}
+		default:
+			return nil, nil, fmt.Errorf("unsupported nesting mode %s of block %s", b.Nesting, b.TypeName)
+		}
+	}
+
+	return attributes, blocks, nil
+}
+
+func frameworkProviderAttribute(a *tfprotov5.SchemaAttribute) (providerschema.Attribute, error) {
+	var deprecationMessage string
+	if a.Deprecated {
+		deprecationMessage = "Deprecated"
+	}
+
+	switch {
+	case a.Type.Is(tftypes.String):
+		return providerschema.StringAttribute{
+			Required:           a.Required,
+			Optional:           a.Optional,
+			Sensitive:          a.Sensitive,
+			Description:        a.Description,
+			DeprecationMessage: deprecationMessage,
+		}, nil
+	case a.Type.Is(tftypes.Bool):
+		return providerschema.BoolAttribute{
+			Required:           a.Required,
+			Optional:           a.Optional,
+			Sensitive:          a.Sensitive,
+			Description:        a.Description,
+			DeprecationMessage: deprecationMessage,
+		}, nil
+	case a.Type.Is(tftypes.Number):
+		return providerschema.NumberAttribute{
+			Required:           a.Required,
+			Optional:           a.Optional,
+			Sensitive:          a.Sensitive,
+			Description:        a.Description,
+			DeprecationMessage: deprecationMessage,
+		}, nil
+	case a.Type.Is(tftypes.List{}):
+		elemType, err := frameworkType(a.Type.(tftypes.List).ElementType)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %s", a.Name, err)
+		}
+		return providerschema.ListAttribute{
+			ElementType:        elemType,
+			Required:           a.Required,
+			Optional:           a.Optional,
+			Sensitive:          a.Sensitive,
+			Description:        a.Description,
+			DeprecationMessage: deprecationMessage,
+		}, nil
+	case a.Type.Is(tftypes.Map{}):
+		elemType, err := frameworkType(a.Type.(tftypes.Map).ElementType)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %s", a.Name, err)
+		}
+		return providerschema.MapAttribute{
+			ElementType:        elemType,
+			Required:           a.Required,
+			Optional:           a.Optional,
+			Sensitive:          a.Sensitive,
+			Description:        a.Description,
+			DeprecationMessage: deprecationMessage,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s of attribute %s", a.Type, a.Name)
+	}
+}
+
+func frameworkType(t tftypes.Type) (attr.Type, error) {
+	switch {
+	case t.Is(tftypes.String):
+		return types.StringType, nil
+	case t.Is(tftypes.Bool):
+		return types.BoolType, nil
+	case t.Is(tftypes.Number):
+		return types.NumberType, nil
+	default:
+		return nil, fmt.Errorf("unsupported element type %s", t)
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// defaultConfigFile is the config file read when config_file is not set.
+const defaultConfigFile = "~/.vcda/config"
+
+// profileKeys lists the provider settings that a profile can hold.
+var profileKeys = map[string]bool{
+	"vcda_ip":                      true,
+	"local_user":                   true,
+	"local_password":               true,
+	"local_password_command":       true,
+	"auth_type":                    true,
+	"auth_user":                    true,
+	"auth_password":                true,
+	"auth_password_command":        true,
+	"vsphere_user":                 true,
+	"vsphere_password":             true,
+	"vsphere_password_command":     true,
+	"vsphere_server":               true,
+	"vsphere_allow_unverified_ssl": true,
+	"ca_bundle":                    true,
+	"ca_bundle_file":               true,
+	"vcda_certificate_pinning":     true,
+	"api_timeout":                  true,
+	"vim_keep_alive":               true,
+	"persist_session":              true,
+	"vim_session_path":             true,
+	"client_debug":                 true,
+	"client_debug_path":            true,
+}
+
+// providerProfile holds the provider settings of a named section of the config file.
+type providerProfile map[string]string
+
+// expandHome replaces a leading ~ of name with the home directory.
+func expandHome(name string) (string, error) {
+	if name != "~" && !strings.HasPrefix(name, "~/") {
+		return name, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find the home directory: %s", err)
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(name, "~")), nil
+}
+
+// loadProfile reads the profile with the given name from the config file.
+// The file holds INI style sections, one per profile, of key = value lines
+// whose keys are provider argument names. Lines starting with # or ; are
+// comments.
+func loadProfile(fileName string, name string) (providerProfile, error) {
+	fileName, err := expandHome(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Clean(fileName))
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %s", err)
+	}
+	defer file.Close()
+
+	profiles, err := parseProfiles(bufio.NewScanner(file))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %s", fileName, err)
+	}
+
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found in config file %s", name, fileName)
+	}
+
+	return profile, nil
+}
+
+func parseProfiles(scanner *bufio.Scanner) (map[string]providerProfile, error) {
+	profiles := make(map[string]providerProfile)
+
+	var current providerProfile
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty profile name", lineNumber)
+			}
+			if _, ok := profiles[name]; ok {
+				return nil, fmt.Errorf("line %d: duplicate profile %q", lineNumber, name)
+			}
+			current = make(providerProfile)
+			profiles[name] = current
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNumber)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: setting outside of a profile", lineNumber)
+		}
+
+		key = strings.TrimSpace(key)
+		if !profileKeys[key] {
+			return nil, fmt.Errorf("line %d: unsupported setting %q", lineNumber, key)
+		}
+		current[key] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+// providerSettings resolves the provider settings. The provider
+// configuration and the environment variables take precedence over the
+// selected profile.
+type providerSettings struct {
+	d       *schema.ResourceData
+	profile providerProfile
+}
+
+func newProviderSettings(d *schema.ResourceData) (*providerSettings, error) {
+	s := &providerSettings{d: d}
+
+	name := d.Get("profile").(string)
+	if name == "" {
+		return s, nil
+	}
+
+	fileName := d.Get("config_file").(string)
+	if fileName == "" {
+		fileName = defaultConfigFile
+	}
+
+	profile, err := loadProfile(fileName, name)
+	if err != nil {
+		return nil, err
+	}
+	s.profile = profile
+
+	return s, nil
+}
+
+// String returns the string setting key.
+func (s *providerSettings) String(key string) string {
+	if v := s.d.Get(key).(string); v != "" {
+		return v
+	}
+
+	return s.profile[key]
+}
+
+// Bool returns the boolean setting key, which can also be set with the
+// environment variable envName.
+func (s *providerSettings) Bool(key string, envName string) (bool, error) {
+	value, ok := s.profile[key]
+	if !ok || s.configured(key) || os.Getenv(envName) != "" {
+		return s.d.Get(key).(bool), nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s in profile: %s", key, err)
+	}
+
+	return b, nil
+}
+
+// configured reports whether key is set in the provider configuration.
+func (s *providerSettings) configured(key string) bool {
+	config := s.d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return false
+	}
+
+	return !config.GetAttr(key).IsNull()
+}
+
+// Int returns the integer setting key, which can also be set with the
+// environment variable envName.
+func (s *providerSettings) Int(key string, envName string) (int, error) {
+	value, ok := s.profile[key]
+	if !ok || s.configured(key) || os.Getenv(envName) != "" {
+		return s.d.Get(key).(int), nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s in profile: %s", key, err)
+	}
+
+	return i, nil
+}
+
+// Duration returns the duration setting key, or defaultValue when it is not
+// set.
+func (s *providerSettings) Duration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := s.String(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", key, err)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be positive", key)
+	}
+
+	return duration, nil
+}
+
+// vSphereSettings lists the settings of the vSphere connection, which must be
+// set together, and their environment variables.
+var vSphereSettings = []struct {
+	Key     string
+	EnvName string
+	Command string
+}{
+	{"vsphere_user", VsphereUser, ""},
+	{"vsphere_password", VspherePassword, "vsphere_password_command"},
+	{"vsphere_server", VsphereServer, ""},
+}
+
+// isSet reports whether key, or its command when not empty, is set.
+func (s *providerSettings) isSet(key string, command string) bool {
+	return s.String(key) != "" || (command != "" && s.String(command) != "")
+}
+
+// vSphereConfigured reports whether any vSphere connection setting is set.
+func (s *providerSettings) vSphereConfigured() bool {
+	for _, setting := range vSphereSettings {
+		if s.isSet(setting.Key, setting.Command) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkRequired returns an error naming the first required setting that is
+// not set. The vSphere connection is optional, but its settings must be set
+// together.
+func (s *providerSettings) checkRequired() error {
+	if s.String("vcda_ip") == "" {
+		return fmt.Errorf("vcda_ip must be set in the provider configuration, the %s environment variable or the profile", VcdaIP)
+	}
+
+	if !s.vSphereConfigured() {
+		return nil
+	}
+	for _, setting := range vSphereSettings {
+		if !s.isSet(setting.Key, setting.Command) {
+			return fmt.Errorf("%s must be set in the provider configuration, the %s environment variable or the profile, "+
+				"since the vSphere connection is configured", setting.Key, setting.EnvName)
+		}
+	}
+
+	return nil
+}
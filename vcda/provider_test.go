@@ -6,10 +6,20 @@
 package vcda
 
 import (
+	"bufio"
+	"context"
+	"net/http"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 var testAccProviders map[string]*schema.Provider
@@ -33,6 +43,136 @@ func (at *AccTests) TestProvider_impl() {
 	var _ = Provider()
 }
 
+func (at *AccTests) TestProvider_mux(t *testing.T) {
+	serverFactory, err := ProviderServerFactory(context.Background())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, err := serverFactory().GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, d := range resp.Diagnostics {
+		t.Errorf("%s: %s", d.Summary, d.Detail)
+	}
+}
+
+func (at *AccTests) TestProvider_profile(t *testing.T) {
+	config := `
+# lab site
+[lab]
+vcda_ip        = 10.0.0.10
+vsphere_server = "vc.lab.local"
+
+[production]
+vcda_ip     = 10.1.0.10
+api_timeout = 10m
+`
+	profiles, err := parseProfiles(bufio.NewScanner(strings.NewReader(config)))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if v := profiles["lab"]["vsphere_server"]; v != "vc.lab.local" {
+		t.Errorf("unexpected vsphere_server: %q", v)
+	}
+	if v := profiles["production"]["api_timeout"]; v != "10m" {
+		t.Errorf("unexpected api_timeout: %q", v)
+	}
+
+	_, err = parseProfiles(bufio.NewScanner(strings.NewReader("[lab]\nvcenter_server = vc.lab.local\n")))
+	if err == nil {
+		t.Errorf("expected an error for an unsupported setting")
+	}
+}
+
+func (at *AccTests) TestProvider_credentialCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test commands require a POSIX shell")
+	}
+
+	c := &Client{}
+	secret, err := c.commandOrSecret("echo ' s3cret '", "ignored")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if secret != "s3cret" {
+		t.Errorf("unexpected secret: %q", secret)
+	}
+
+	if _, err := c.commandOrSecret("echo denied >&2; exit 1", ""); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("expected an error with the command stderr, got: %v", err)
+	}
+
+	if _, err := c.commandOrSecret("true", ""); err == nil {
+		t.Errorf("expected an error for an empty secret")
+	}
+}
+
+func (at *AccTests) TestProvider_logRedaction(t *testing.T) {
+	body := redactBody([]byte(`{"type":"localUser","localUser":"root","localPassword":"s3cret",` +
+		`"details":[{"rootPassword":"s3cret","ssoPassword":"s3cret"}],"key":"license"}`))
+	if strings.Contains(body, "s3cret") || strings.Contains(body, "license") {
+		t.Errorf("secrets not redacted: %s", body)
+	}
+	if !strings.Contains(body, `"localUser":"root"`) {
+		t.Errorf("unexpected redaction: %s", body)
+	}
+
+	header := http.Header{}
+	header.Set(VcdaAuthTokenHeader, "token")
+	header.Set(ConfigSecretHeader, "s3cret")
+	for name, value := range redactHeaders(header) {
+		if value != redactedValue {
+			t.Errorf("header %s not redacted: %s", name, value)
+		}
+	}
+}
+
+func (at *AccTests) TestProvider_tracing(t *testing.T) {
+	tracesFile := filepath.Join(t.TempDir(), "traces.json")
+	t.Setenv(TracesFile, tracesFile)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	shutdown, err := InitTracing(context.Background())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	read := traceCRUD("vcda_tunnel", "Read", func(ctx context.Context, _ *schema.ResourceData, _ interface{}) diag.Diagnostics {
+		_, span := startTaskPollSpan(ctx, "pair_site", "task-1")
+		endSpan(span, nil)
+		return nil
+	})
+	read(context.Background(), resourceVcdaTunnel().TestResourceData(), nil)
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	data, err := os.ReadFile(tracesFile)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, want := range []string{`"Name":"vcda_tunnel.Read"`, `"Name":"wait pair_site"`, `"task-1"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("traces do not contain %s: %s", want, data)
+		}
+	}
+}
+
+func testProtoV5ProviderFactories() map[string]func() (tfprotov5.ProviderServer, error) {
+	return map[string]func() (tfprotov5.ProviderServer, error){
+		"vcda": func() (tfprotov5.ProviderServer, error) {
+			serverFactory, err := ProviderServerFactory(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			return serverFactory(), nil
+		},
+	}
+}
+
 func testProviders() map[string]func() (*schema.Provider, error) {
 	return map[string]func() (*schema.Provider, error){
 		"vcda": func() (*schema.Provider, error) { return Provider(), nil },
@@ -70,6 +210,24 @@ func TestRunner(t *testing.T) {
 		test := AccTests{Test: t}
 		test.TestProvider(t)
 		test.TestProvider_impl()
+		test.TestProvider_mux(t)
+		test.TestProvider_profile(t)
+		test.TestProvider_credentialCommand(t)
+		test.TestProvider_logRedaction(t)
+		test.TestProvider_tracing(t)
+		test.TestResourceSteps_applyCreateSteps(t)
+		test.TestResourceSteps_checkAdoptable(t)
+		test.TestOnDestroy_removeConfigs(t)
+		test.TestVcdaDataSourceTunnels_setTunnelsData(t)
+		test.TestVcdaTunnel_sameCertificate(t)
+		test.TestVcdaTunnel_liveCertificate(t)
+		test.TestVcdaReplicator_dataAddress(t)
+		test.TestVcdaTrafficSettings_expandTrafficSettings(t)
+		test.TestVcdaReplicatorPool_forEachPoolMember(t)
+		test.TestVimClient_restoreSession(t)
+		test.TestVimClient_passwordCommand(t)
+		test.TestServiceCert_roleForPort(t)
+		test.TestVcdaReplicator_forceDelete(t)
 	})
 
 	t.Run("cloud", func(t *testing.T) {
@@ -78,12 +236,15 @@ func TestRunner(t *testing.T) {
 		test.TestAccVcdaCloudDirectorReplicationManager_basic(t)
 		test.TestAccVcdaTunnel_basic(t)
 		test.TestAccVcdaDataSourceCloudHealth_basic(t)
+		test.TestAccVcdaDataSourceTunnels_basic(t)
+		test.TestAccVcdaTrafficSettings_basic(t)
 	})
 
 	t.Run("manager", func(t *testing.T) {
 		test := AccTests{Test: t}
 		test.TestAccVcdaVcenterReplicationManager_basic(t)
 		test.TestAccVcdaReplicator_basic(t)
+		test.TestAccVcdaReplicatorPool_basic(t)
 		test.TestAccVcdaDataSourceManagerHealth_basic(t)
 		test.TestAccVcdaDataSourceReplicatorHealth_basic(t)
 		test.TestAccVcdaDataSourceTunnelConnectivity_basic(t)
@@ -98,5 +259,8 @@ func TestRunner(t *testing.T) {
 		test := AccTests{Test: t}
 		test.TestAccVcdaDataSourceRemoteServicesThumbprint_basic(t)
 		test.TestAccVcdaDataSourceServiceCert_basic(t)
+		test.TestAccVcdaDataSourceAppliances_basic(t)
+		test.TestAccVcdaEphemeralSessionToken_basic(t)
+		test.TestAccVcdaEphemeralServiceCert_basic(t)
 	})
 }
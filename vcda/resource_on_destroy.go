@@ -0,0 +1,138 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// The behaviors of destroying a resource that configures an appliance.
+const (
+	// onDestroyForget removes the resource from the state only.
+	onDestroyForget = "forget"
+	// onDestroyUnconfigure removes the configuration that the resource
+	// applied.
+	onDestroyUnconfigure = "unconfigure"
+	// onDestroyFactoryReset resets the whole configuration of the appliance.
+	onDestroyFactoryReset = "factory_reset"
+)
+
+// onDestroySchema returns the schema of the argument that selects what
+// destroying the resource does to the appliance, with the given default.
+func onDestroySchema(defaultMode string, modes ...string) *schema.Schema {
+	descriptions := map[string]string{
+		onDestroyForget:       "`forget` only removes the resource from the state.",
+		onDestroyUnconfigure:  "`unconfigure` removes the configuration applied by the resource, where the API allows it.",
+		onDestroyFactoryReset: "`factory_reset` resets the whole configuration of the appliance.",
+	}
+
+	description := "What destroying the resource does to the appliance, one of `" + strings.Join(modes, "`, `") + "`."
+	for _, mode := range modes {
+		description += " " + descriptions[mode]
+	}
+
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Description:  description,
+		Optional:     true,
+		Default:      defaultMode,
+		ValidateFunc: validation.StringInSlice(modes, false),
+	}
+}
+
+// configRemoval is a piece of configuration that is removed when a resource
+// is destroyed with on_destroy set to unconfigure.
+type configRemoval struct {
+	name   string
+	remove func(ctx context.Context) (*Task, error)
+}
+
+// destroyAppliance removes the configuration of an appliance as selected by
+// the on_destroy argument: the removals for unconfigure, or a reset of the
+// whole configuration for factory_reset.
+func destroyAppliance(ctx context.Context, d *schema.ResourceData, c *Client, serviceCert string, removals []configRemoval) diag.Diagnostics {
+	switch d.Get("on_destroy").(string) {
+	case onDestroyUnconfigure:
+		return removeConfigs(ctx, c, serviceCert, d.Timeout(schema.TimeoutDelete), removals)
+	case onDestroyFactoryReset:
+		return removeConfigs(ctx, c, serviceCert, d.Timeout(schema.TimeoutDelete), []configRemoval{
+			{
+				name: "configuration",
+				remove: func(ctx context.Context) (*Task, error) {
+					return c.resetConfig(ctx, serviceCert)
+				},
+			},
+		})
+	}
+
+	return nil
+}
+
+// removeConfigs removes the configuration in order and waits for the tasks
+// that remove it asynchronously. The removals that the appliance does not
+// support are skipped with a warning.
+func removeConfigs(ctx context.Context, c *Client, serviceCert string, timeout time.Duration, removals []configRemoval) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for _, removal := range removals {
+		task, err := removal.remove(ctx)
+		if isUnsupported(err) {
+			tflog.Warn(ctx, "Skipped configuration removal that the appliance does not support", map[string]interface{}{"configuration": removal.name})
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "could not remove " + removal.name,
+				Detail:   "The appliance does not support removing the " + removal.name + ", which is left configured.",
+			})
+			continue
+		}
+		if err == nil && task != nil {
+			err = waitForTask(ctx, c, serviceCert, timeout, "remove "+removal.name, task.ID)
+		}
+		if err != nil {
+			return append(diags, diag.Errorf("error removing %s: %s", removal.name, err)...)
+		}
+	}
+
+	return diags
+}
+
+// waitForTask waits for the task with the given ID to complete.
+func waitForTask(ctx context.Context, c *Client, serviceCert string, timeout time.Duration, taskType string, taskID string) error {
+	return waitForTaskOn(ctx, c, c.VcdaIP, serviceCert, timeout, taskType, taskID)
+}
+
+// waitForTaskOn waits for the task with the given ID of the service at host
+// to complete.
+func waitForTaskOn(ctx context.Context, c *Client, host string, serviceCert string, timeout time.Duration, taskType string, taskID string) error {
+	pollCtx, span := startTaskPollSpan(ctx, taskType, taskID)
+	err := retry.RetryContext(pollCtx, timeout, func() *retry.RetryError {
+		task, err := c.getTaskOn(pollCtx, host, serviceCert, taskID)
+
+		if err != nil {
+			return retry.NonRetryableError(err)
+		}
+
+		if task.State == "FAILED" {
+			return retry.NonRetryableError(fmt.Errorf("%s task failed with Code: %s, Msg: %s", taskType, task.Error.Code, task.Error.Msg))
+		} else if task.State == "RUNNING" {
+			return retry.RetryableError(fmt.Errorf("expected %s task to be completed but was in state %s", taskType, task.State))
+		}
+
+		return nil
+	})
+	endSpan(span, err)
+
+	return err
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// TestOnDestroy_removeConfigs skips the removals that the appliance does not
+// support and stops at a failed removal.
+func (at *AccTests) TestOnDestroy_removeConfigs(t *testing.T) {
+	var removed []string
+	removal := func(name string, err error) configRemoval {
+		return configRemoval{
+			name: name,
+			remove: func(context.Context) (*Task, error) {
+				removed = append(removed, name)
+				return nil, err
+			},
+		}
+	}
+
+	diags := removeConfigs(context.Background(), &Client{}, "", time.Minute, []configRemoval{
+		removal("license", &apiError{StatusCode: http.StatusNotFound}),
+		removal("lookup service", nil),
+	})
+	if diags.HasError() || len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Errorf("expected an unsupported removal to be skipped with a warning: %v", diags)
+	}
+
+	diags = removeConfigs(context.Background(), &Client{}, "", time.Minute, []configRemoval{
+		removal("tunnel", &apiError{StatusCode: http.StatusInternalServerError}),
+		removal("license", nil),
+	})
+	if !diags.HasError() {
+		t.Error("expected a failed removal to fail the destroy")
+	}
+	if got := fmt.Sprint(removed); got != "[license lookup service tunnel]" {
+		t.Errorf("unexpected removals: %s", got)
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// updateStep is a group of attributes that are updated with a single API
+// call.
+type updateStep struct {
+	name    string
+	changed bool
+	apply   func(ctx context.Context) error
+}
+
+// applyUpdateSteps applies, in order, every step whose attributes have
+// changed. When a step fails the prior state is kept, so that the remaining
+// changes are planned again, and the error reports the steps that were
+// already applied.
+func applyUpdateSteps(ctx context.Context, d *schema.ResourceData, steps []updateStep) diag.Diagnostics {
+	d.Partial(true)
+
+	var applied []string
+	for _, step := range steps {
+		if !step.changed {
+			continue
+		}
+
+		if err := step.apply(ctx); err != nil {
+			detail := "No changes were applied."
+			if len(applied) > 0 {
+				detail = "The following changes were applied: " + strings.Join(applied, ", ") + "."
+			}
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "error updating " + step.name + ": " + err.Error(),
+				Detail:   detail,
+			}}
+		}
+		applied = append(applied, step.name)
+	}
+
+	d.Partial(false)
+
+	return nil
+}
+
+// createStep is a step of a create that configures an appliance with several
+// API calls.
+type createStep struct {
+	name string
+	// done, if set, reports whether the appliance already has the step
+	// applied, so that a create that failed midway resumes from the failed
+	// step.
+	done  func(ctx context.Context) (bool, error)
+	apply func(ctx context.Context) error
+	// undo, if set, reverts the step when the create fails in rollback mode.
+	undo func(ctx context.Context) error
+}
+
+// completedStepsSchema returns the schema of the attribute that records the
+// completed create steps.
+func completedStepsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "The configuration steps that were completed by the create.",
+		Computed:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+	}
+}
+
+// rollbackOnFailureSchema returns the schema of the flag that reverts the
+// completed create steps when a later step fails.
+func rollbackOnFailureSchema() *schema.Schema {
+	return &schema.Schema{
+		Type: schema.TypeBool,
+		Description: "Whether to revert the completed configuration steps when a later step of the create fails. " +
+			"The steps that cannot be reverted are left applied.",
+		Optional: true,
+		Default:  false,
+	}
+}
+
+// applyCreateSteps applies the steps in order and records the completed ones
+// in the completed_steps attribute. The steps that are already recorded as
+// completed, or that the appliance already has applied, are skipped. When a
+// step fails, the steps applied by this run are reverted in rollback mode, or
+// else kept and recorded so that the next apply resumes from the failed step.
+func applyCreateSteps(ctx context.Context, d *schema.ResourceData, steps []createStep) diag.Diagnostics {
+	var completed []string
+	recorded := make(map[string]bool)
+	for _, name := range d.Get("completed_steps").([]interface{}) {
+		completed = append(completed, name.(string))
+		recorded[name.(string)] = true
+	}
+	resuming := len(completed) > 0
+
+	var applied []createStep
+	for _, step := range steps {
+		if recorded[step.name] {
+			tflog.Info(ctx, "Skipped configuration step that is recorded as completed", map[string]interface{}{"step": step.name})
+			continue
+		}
+
+		skipped, err := applyCreateStep(ctx, step)
+		if err == nil {
+			completed = append(completed, step.name)
+			if skipped {
+				tflog.Info(ctx, "Skipped configuration step that is already applied", map[string]interface{}{"step": step.name})
+			} else {
+				applied = append(applied, step)
+			}
+		}
+
+		if setErr := d.Set("completed_steps", completed); setErr != nil {
+			return diag.Errorf("error setting completed_steps field: %s", setErr)
+		}
+
+		if err != nil {
+			summary := "error applying " + step.name + " step: " + err.Error()
+			if d.Get("rollback_on_failure").(bool) {
+				// a resumed create keeps the resource, whose earlier steps
+				// were completed by a prior apply
+				if !resuming {
+					d.SetId("")
+				}
+				return diag.Diagnostics{{Severity: diag.Error, Summary: summary, Detail: rollbackCreateSteps(ctx, applied)}}
+			}
+
+			detail := "No steps were completed."
+			if len(completed) > 0 {
+				detail = "The following steps were completed: " + strings.Join(completed, ", ") + "."
+			}
+			switch {
+			case resuming:
+				detail += " The next apply resumes from the " + step.name + " step."
+			case d.Id() != "":
+				detail += " The resource is tainted: untaint it to resume from the " + step.name + " step on the next apply, " +
+					"which otherwise replaces it and skips the steps that the appliance already has applied."
+			default:
+				detail += " The next apply skips the steps that the appliance already has applied."
+			}
+			return diag.Diagnostics{{Severity: diag.Error, Summary: summary, Detail: detail}}
+		}
+	}
+
+	return nil
+}
+
+// hasPendingCreateSteps reports whether a create that failed midway recorded
+// some of the named steps as completed, but not all of them.
+func hasPendingCreateSteps(completed []interface{}, names []string) bool {
+	if len(completed) == 0 {
+		return false
+	}
+
+	recorded := make(map[string]bool)
+	for _, name := range completed {
+		recorded[name.(string)] = true
+	}
+	for _, name := range names {
+		if !recorded[name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resumeCreateStepsCustomizeDiff plans an update that resumes a create that
+// failed midway, once its resource is untainted.
+func resumeCreateStepsCustomizeDiff(names []string) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+		if d.Id() == "" || !hasPendingCreateSteps(d.Get("completed_steps").([]interface{}), names) {
+			return nil
+		}
+
+		return d.SetNewComputed("completed_steps")
+	}
+}
+
+// undoRemoval returns the undo of a step that removes its configuration with
+// remove and waits for the task of the service at host that removes it.
+func undoRemoval(c *Client, host string, serviceCert string, timeout time.Duration, name string, remove func(ctx context.Context) (*Task, error)) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		task, err := remove(ctx)
+		if err == nil && task != nil {
+			err = waitForTaskOn(ctx, c, host, serviceCert, timeout, "remove "+name, task.ID)
+		}
+
+		return err
+	}
+}
+
+// applyCreateStep applies step, unless the appliance already has it applied,
+// and reports whether it was skipped.
+func applyCreateStep(ctx context.Context, step createStep) (bool, error) {
+	if step.done != nil {
+		done, err := step.done(ctx)
+		if err != nil {
+			return false, err
+		}
+		if done {
+			return true, nil
+		}
+	}
+
+	return false, step.apply(ctx)
+}
+
+// rollbackCreateSteps reverts the applied steps in reverse order and
+// describes the outcome.
+func rollbackCreateSteps(ctx context.Context, applied []createStep) string {
+	var reverted, kept []string
+	for i := len(applied) - 1; i >= 0; i-- {
+		step := applied[i]
+		if step.undo == nil {
+			kept = append(kept, step.name)
+			continue
+		}
+		if err := step.undo(ctx); err != nil {
+			kept = append(kept, fmt.Sprintf("%s (%s)", step.name, err))
+			continue
+		}
+		reverted = append(reverted, step.name)
+	}
+
+	if len(reverted) == 0 && len(kept) == 0 {
+		return "No steps were completed."
+	}
+
+	detail := ""
+	if len(reverted) > 0 {
+		detail = "The following steps were reverted: " + strings.Join(reverted, ", ") + ". "
+	}
+	if len(kept) > 0 {
+		detail += "The following steps could not be reverted and are left applied: " + strings.Join(kept, ", ") + "."
+	}
+
+	return strings.TrimSpace(detail)
+}
+
+// adoptExistingSchema returns the schema of the flag that adopts the current
+// settings of an appliance that is already configured.
+func adoptExistingSchema() *schema.Schema {
+	return &schema.Schema{
+		Type: schema.TypeBool,
+		Description: "Whether to adopt an appliance that is already configured. The current settings of the appliance " +
+			"are adopted into the state without being applied, so that the next plan shows the settings that differ from the configuration. " +
+			"When neither `adopt_existing` nor `overwrite_existing` is set, the create fails on an already configured appliance.",
+		Optional:      true,
+		Default:       false,
+		ConflictsWith: []string{"overwrite_existing"},
+	}
+}
+
+// overwriteExistingSchema returns the schema of the flag that applies the
+// configuration to an appliance that is already configured.
+func overwriteExistingSchema() *schema.Schema {
+	return &schema.Schema{
+		Type: schema.TypeBool,
+		Description: "Whether to apply the configuration to an appliance that is already configured, overwriting " +
+			"its settings that differ from the configuration. " +
+			"When neither `adopt_existing` nor `overwrite_existing` is set, the create fails on an already configured appliance.",
+		Optional:      true,
+		Default:       false,
+		ConflictsWith: []string{"adopt_existing"},
+	}
+}
+
+// settingDiff is a setting that an already configured appliance has with a
+// value that differs from the configuration.
+type settingDiff struct {
+	name    string
+	current interface{}
+	desired interface{}
+}
+
+func (diff settingDiff) String() string {
+	return fmt.Sprintf("  ~ %s: %q => %q", diff.name, fmt.Sprint(diff.current), fmt.Sprint(diff.desired))
+}
+
+// settingDiffs lists the settings that an already configured appliance has
+// with values that differ from the configuration.
+type settingDiffs []settingDiff
+
+// add records the setting when its current value differs from the desired
+// one.
+func (diffs *settingDiffs) add(name string, current interface{}, desired interface{}) {
+	if current != desired {
+		*diffs = append(*diffs, settingDiff{name: name, current: current, desired: desired})
+	}
+}
+
+// adopt stores the current values of the differing settings in the state.
+func (diffs settingDiffs) adopt(d *schema.ResourceData) error {
+	for _, diff := range diffs {
+		if err := d.Set(diff.name, diff.current); err != nil {
+			return fmt.Errorf("error setting %s field: %s", diff.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (diffs settingDiffs) String() string {
+	lines := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		lines = append(lines, diff.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// checkAdoptable fails on an already configured appliance, unless
+// adopt_existing or overwrite_existing is set, and lists the settings that
+// differ from the configuration. When the settings are adopted, the
+// differing ones are reported as a warning.
+func checkAdoptable(d *schema.ResourceData, diffs settingDiffs) diag.Diagnostics {
+	if d.Get("overwrite_existing").(bool) {
+		return nil
+	}
+
+	if d.Get("adopt_existing").(bool) {
+		if len(diffs) == 0 {
+			return nil
+		}
+
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "the adopted appliance has settings that differ from the configuration",
+			Detail: "The following settings differ from the configuration and are planned by the next plan:\n\n" +
+				diffs.String(),
+		}}
+	}
+
+	detail := "The settings of the appliance match the configuration."
+	if len(diffs) > 0 {
+		detail = "The following settings differ from the configuration:\n\n" + diffs.String()
+	}
+
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "the appliance is already configured",
+		Detail: detail + "\n\nSet adopt_existing to adopt the current settings into the state, " +
+			"or overwrite_existing to apply the configuration.",
+	}}
+}
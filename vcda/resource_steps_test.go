@@ -0,0 +1,136 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestResourceSteps_applyCreateSteps applies create steps that fail midway,
+// with and without rollback, and resumes them from the recorded steps.
+func (at *AccTests) TestResourceSteps_applyCreateSteps(t *testing.T) {
+	var calls []string
+	step := func(name string, done bool, fail bool) createStep {
+		return createStep{
+			name: name,
+			done: func(context.Context) (bool, error) { return done, nil },
+			apply: func(context.Context) error {
+				calls = append(calls, "apply "+name)
+				if fail {
+					return fmt.Errorf("%s failed", name)
+				}
+				return nil
+			},
+			undo: func(context.Context) error {
+				calls = append(calls, "undo "+name)
+				return nil
+			},
+		}
+	}
+
+	d := resourceVcdaReplicator().TestResourceData()
+	d.SetId("replicator")
+	diags := applyCreateSteps(context.Background(), d, []createStep{step("first", true, false), step("second", false, false), step("third", false, true)})
+	if !diags.HasError() {
+		t.Fatal("expected an error")
+	}
+	if got := fmt.Sprint(calls); got != "[apply second apply third]" {
+		t.Errorf("unexpected calls: %s", got)
+	}
+	if got := fmt.Sprint(d.Get("completed_steps")); got != "[first second]" {
+		t.Errorf("unexpected completed steps: %s", got)
+	}
+	if d.Id() != "replicator" {
+		t.Error("expected the ID to be kept")
+	}
+
+	calls = nil
+	d = resourceVcdaReplicator().TestResourceData()
+	d.SetId("replicator")
+	if err := d.Set("rollback_on_failure", true); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	diags = applyCreateSteps(context.Background(), d, []createStep{step("first", true, false), step("second", false, false), step("third", false, true)})
+	if !diags.HasError() {
+		t.Fatal("expected an error")
+	}
+	if got := fmt.Sprint(calls); got != "[apply second apply third undo second]" {
+		t.Errorf("unexpected calls: %s", got)
+	}
+	if d.Id() != "" {
+		t.Error("expected the ID to be cleared")
+	}
+
+	calls = nil
+	d = resourceVcdaReplicator().TestResourceData()
+	d.SetId("replicator")
+	if err := d.Set("completed_steps", []string{"first", "second"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	names := []string{"first", "second", "third"}
+	if !hasPendingCreateSteps(d.Get("completed_steps").([]interface{}), names) {
+		t.Error("expected the third step to be pending")
+	}
+	diags = applyCreateSteps(context.Background(), d, []createStep{step("first", false, false), step("second", false, false), step("third", false, false)})
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if got := fmt.Sprint(calls); got != "[apply third]" {
+		t.Errorf("expected the recorded steps to be skipped, got calls: %s", got)
+	}
+	if hasPendingCreateSteps(d.Get("completed_steps").([]interface{}), names) {
+		t.Error("expected no pending steps")
+	}
+	if hasPendingCreateSteps(nil, names) {
+		t.Error("expected a resource without recorded steps not to resume")
+	}
+}
+
+// TestResourceSteps_checkAdoptable compares the settings of an already
+// configured appliance with the configuration, and adopts or overwrites
+// them as requested.
+func (at *AccTests) TestResourceSteps_checkAdoptable(t *testing.T) {
+	var diffs settingDiffs
+	diffs.add("site_name", "site-a", "site-a")
+	diffs.add("lookup_service_thumbprint", normalizeThumbprint("SHA-256:aa:bb"), normalizeThumbprint("AA:BB"))
+	diffs.add("lookup_service_url", "https://vc-a/lookupservice/sdk", "https://vc-b/lookupservice/sdk")
+	if len(diffs) != 1 || !strings.Contains(diffs.String(), `lookup_service_url: "https://vc-a/lookupservice/sdk" => "https://vc-b/lookupservice/sdk"`) {
+		t.Fatalf("unexpected diffs: %v", diffs)
+	}
+
+	d := resourceVcdaVcenterReplicationManager().TestResourceData()
+	if diags := checkAdoptable(d, nil); !diags.HasError() {
+		t.Error("expected a configured appliance to fail the create, even with matching settings")
+	}
+
+	if err := d.Set("overwrite_existing", true); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if diags := checkAdoptable(d, diffs); len(diags) != 0 {
+		t.Errorf("expected differing settings to be overwritten: %v", diags)
+	}
+
+	d = resourceVcdaVcenterReplicationManager().TestResourceData()
+	if err := d.Set("lookup_service_url", "https://vc-b/lookupservice/sdk"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := d.Set("adopt_existing", true); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	diags := checkAdoptable(d, diffs)
+	if diags.HasError() || len(diags) != 1 {
+		t.Errorf("expected differing settings to be adopted with a warning: %v", diags)
+	}
+	if err := diffs.adopt(d); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if got := d.Get("lookup_service_url"); got != "https://vc-a/lookupservice/sdk" {
+		t.Errorf("expected the current setting to be adopted into the state, got %s", got)
+	}
+}
@@ -7,7 +7,9 @@ package vcda
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -16,6 +18,7 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceVcdaAppliancePassword() *schema.Resource {
@@ -24,6 +27,7 @@ func resourceVcdaAppliancePassword() *schema.Resource {
 		ReadContext:   resourceAppliancePasswordRead,
 		UpdateContext: resourceAppliancePasswordUpdate,
 		DeleteContext: resourceAppliancePasswordDelete,
+		CustomizeDiff: resourceAppliancePasswordCustomizeDiff,
 		Schema: map[string]*schema.Schema{
 			"current_password": {
 				Type:        schema.TypeString,
@@ -35,21 +39,78 @@ func resourceVcdaAppliancePassword() *schema.Resource {
 				Type:      schema.TypeString,
 				Sensitive: true,
 				Description: "The new password of the appliance. Note: This value is never returned on read. " +
-					"On creation, include either `new_password` or `password_file`.",
+					"On creation, include either `new_password`, `password_file` or `rotation`.",
 				Optional:      true,
-				ConflictsWith: []string{"password_file"},
+				ConflictsWith: []string{"password_file", "rotation"},
 			},
 			"password_file": {
 				Type: schema.TypeString,
 				Description: "The name of a file containing the appliance password. " +
-					"On creation, include either `password_file` or `new_password`.",
+					"On creation, include either `password_file` or `new_password`. " +
+					"With `rotation`, the generated password is written to this file instead.",
 				Optional:      true,
 				ConflictsWith: []string{"new_password"},
 			},
+			"rotation": {
+				Type: schema.TypeList,
+				Description: "Rotates the password with a generated one when the password is expired or expires " +
+					"within `window_days`. The generated password is exposed in `generated_password` and, " +
+					"when set, written to `password_file`.",
+				Optional:      true,
+				MaxItems:      1,
+				ConflictsWith: []string{"new_password"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"window_days": {
+							Type:         schema.TypeInt,
+							Description:  "The number of days before the expiration in which the password is rotated.",
+							Optional:     true,
+							Default:      7,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+						"length": {
+							Type:         schema.TypeInt,
+							Description:  "The length of the generated password.",
+							Optional:     true,
+							Default:      16,
+							ValidateFunc: validation.IntBetween(8, 128),
+						},
+						"min_upper": {
+							Type:         schema.TypeInt,
+							Description:  "The minimum number of uppercase letters in the generated password.",
+							Optional:     true,
+							Default:      1,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+						"min_lower": {
+							Type:         schema.TypeInt,
+							Description:  "The minimum number of lowercase letters in the generated password.",
+							Optional:     true,
+							Default:      1,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+						"min_numeric": {
+							Type:         schema.TypeInt,
+							Description:  "The minimum number of digits in the generated password.",
+							Optional:     true,
+							Default:      1,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+						"min_special": {
+							Type:         schema.TypeInt,
+							Description:  "The minimum number of special characters in the generated password.",
+							Optional:     true,
+							Default:      1,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+					},
+				},
+			},
 			"service_cert": {
-				Type:        schema.TypeString,
-				Description: "The service certificate.",
-				Required:    true,
+				Type: schema.TypeString,
+				Description: "The service certificate. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
 			},
 			"appliance_ip": {
 				Type:        schema.TypeString,
@@ -67,6 +128,24 @@ func resourceVcdaAppliancePassword() *schema.Resource {
 				Description: "Seconds until the **root** user password expires.",
 				Computed:    true,
 			},
+			"generated_password": {
+				Type:        schema.TypeString,
+				Description: "The password generated by the last `rotation`. It is the current password of the appliance.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"rotated_at": {
+				Type:        schema.TypeString,
+				Description: "The time of the last `rotation`, in RFC 3339 format.",
+				Computed:    true,
+			},
+			"root_password_revision": {
+				Type: schema.TypeString,
+				Description: "Changes whenever the password is changed. Pass it to the `root_password_revision` " +
+					"of the `vcda_replicator` and `vcda_tunnel` resources of this appliance to re-register them " +
+					"with the new password in the same apply.",
+				Computed: true,
+			},
 		},
 	}
 
@@ -85,29 +164,44 @@ func resourceAppliancePasswordCreate(ctx context.Context, d *schema.ResourceData
 		return diag.Errorf(`"current_password" cannot be empty`)
 	}
 
+	if rotation, ok := expandPasswordRotation(d); ok {
+		d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
+
+		if err := rotateAppliancePassword(ctx, d, c, rotation, currentPassword); err != nil {
+			d.SetId("")
+			return diag.FromErr(err)
+		}
+
+		return resourceAppliancePasswordRead(ctx, d, m)
+	}
+
 	newPass, err := getNewPasswordInput(newPassword, passwordFile)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	err = c.changePassword(applianceIP, currentPassword, *newPass, serviceCert)
+	err = c.changePassword(ctx, applianceIP, currentPassword, *newPass, serviceCert)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
 	d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
 
+	if err := setRootPasswordRevision(d); err != nil {
+		return diag.FromErr(err)
+	}
+
 	return resourceAppliancePasswordRead(ctx, d, m)
 }
 
-func resourceAppliancePasswordRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func resourceAppliancePasswordRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 	c := m.(*Client)
 
 	applianceIP := d.Get("appliance_ip").(string)
 	serviceCert := d.Get("service_cert").(string)
 
-	passExpiration, err := c.checkPasswordExpired(applianceIP, serviceCert)
+	passExpiration, err := c.checkPasswordExpired(ctx, applianceIP, serviceCert)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -123,6 +217,19 @@ func resourceAppliancePasswordUpdate(ctx context.Context, d *schema.ResourceData
 	var diags diag.Diagnostics
 	c := m.(*Client)
 
+	if rotation, ok := expandPasswordRotation(d); ok {
+		currentPassword := d.Get("current_password").(string)
+		if generated, _ := d.GetChange("generated_password"); generated.(string) != "" {
+			currentPassword = generated.(string)
+		}
+
+		if err := rotateAppliancePassword(ctx, d, c, rotation, currentPassword); err != nil {
+			return diag.FromErr(err)
+		}
+
+		return resourceAppliancePasswordRead(ctx, d, m)
+	}
+
 	if d.HasChange("new_password") || d.HasChange("password_file") {
 		newPassword := d.Get("new_password").(string)
 		passwordFile := d.Get("password_file").(string)
@@ -139,7 +246,7 @@ func resourceAppliancePasswordUpdate(ctx context.Context, d *schema.ResourceData
 			return diag.FromErr(err)
 		}
 
-		err = c.changePassword(applianceIP, currentPassword, *newPass, serviceCert)
+		err = c.changePassword(ctx, applianceIP, currentPassword, *newPass, serviceCert)
 
 		if err != nil {
 			return diag.FromErr(err)
@@ -147,11 +254,55 @@ func resourceAppliancePasswordUpdate(ctx context.Context, d *schema.ResourceData
 
 		d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
 
+		if err := setRootPasswordRevision(d); err != nil {
+			return diag.FromErr(err)
+		}
+
 		return resourceAppliancePasswordRead(ctx, d, m)
 	}
+
 	return diags
 }
 
+// resourceAppliancePasswordCustomizeDiff plans a rotation when the password
+// is expired or expires within the rotation window.
+func resourceAppliancePasswordCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	if d.HasChange("new_password") || d.HasChange("password_file") {
+		if err := d.SetNewComputed("root_password_revision"); err != nil {
+			return err
+		}
+	}
+
+	rotations := d.Get("rotation").([]interface{})
+	if len(rotations) == 0 || rotations[0] == nil {
+		return nil
+	}
+	windowDays := rotations[0].(map[string]interface{})["window_days"].(int)
+
+	expiration := &PasswordExpiration{
+		RootPasswordExpired:    d.Get("root_password_expired").(bool),
+		SecondsUntilExpiration: int64(d.Get("seconds_until_expiration").(int)),
+	}
+
+	if rotationDue(expiration, windowDays) {
+		if err := d.SetNewComputed("generated_password"); err != nil {
+			return err
+		}
+		if err := d.SetNewComputed("rotated_at"); err != nil {
+			return err
+		}
+		if err := d.SetNewComputed("root_password_revision"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func resourceAppliancePasswordDelete(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 
@@ -172,6 +323,158 @@ func setPasswordData(d *schema.ResourceData, passExpiration *PasswordExpiration)
 	return nil
 }
 
+// setRootPasswordRevision records that the password has been changed.
+func setRootPasswordRevision(d *schema.ResourceData) error {
+	if err := d.Set("root_password_revision", strconv.FormatInt(time.Now().UnixNano(), 10)); err != nil {
+		return fmt.Errorf("error setting root_password_revision field: %s", err)
+	}
+
+	return nil
+}
+
+// passwordRotation is the expanded rotation block.
+type passwordRotation struct {
+	WindowDays int
+	Length     int
+	MinUpper   int
+	MinLower   int
+	MinNumeric int
+	MinSpecial int
+}
+
+func expandPasswordRotation(d *schema.ResourceData) (*passwordRotation, bool) {
+	rotations := d.Get("rotation").([]interface{})
+	if len(rotations) == 0 || rotations[0] == nil {
+		return nil, false
+	}
+
+	rotation := rotations[0].(map[string]interface{})
+
+	return &passwordRotation{
+		WindowDays: rotation["window_days"].(int),
+		Length:     rotation["length"].(int),
+		MinUpper:   rotation["min_upper"].(int),
+		MinLower:   rotation["min_lower"].(int),
+		MinNumeric: rotation["min_numeric"].(int),
+		MinSpecial: rotation["min_special"].(int),
+	}, true
+}
+
+// rotationDue reports whether the password is expired or expires within
+// windowDays.
+func rotationDue(expiration *PasswordExpiration, windowDays int) bool {
+	return expiration.RootPasswordExpired || expiration.SecondsUntilExpiration <= int64(windowDays)*24*60*60
+}
+
+// rotateAppliancePassword changes the password to a generated one when the
+// rotation is due, and records the generated password.
+func rotateAppliancePassword(ctx context.Context, d *schema.ResourceData, c *Client, rotation *passwordRotation, currentPassword string) error {
+	applianceIP := d.Get("appliance_ip").(string)
+	serviceCert := d.Get("service_cert").(string)
+
+	passExpiration, err := c.checkPasswordExpired(ctx, applianceIP, serviceCert)
+	if err != nil {
+		return err
+	}
+
+	if !rotationDue(passExpiration, rotation.WindowDays) {
+		return nil
+	}
+
+	newPassword, err := generatePassword(rotation)
+	if err != nil {
+		return err
+	}
+
+	if err := c.changePassword(ctx, applianceIP, currentPassword, newPassword, serviceCert); err != nil {
+		return err
+	}
+
+	// the password is changed at this point, so keep it in the state even if
+	// writing it to the file fails
+	if err := d.Set("generated_password", newPassword); err != nil {
+		return fmt.Errorf("error setting generated_password field: %s", err)
+	}
+
+	if err := d.Set("rotated_at", time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("error setting rotated_at field: %s", err)
+	}
+
+	if err := setRootPasswordRevision(d); err != nil {
+		return err
+	}
+
+	if passwordFile := d.Get("password_file").(string); passwordFile != "" {
+		if err := os.WriteFile(filepath.Clean(passwordFile), []byte(newPassword+"\n"), 0600); err != nil {
+			return fmt.Errorf("could not write the generated password to %s: %s", passwordFile, err)
+		}
+	}
+
+	return nil
+}
+
+const (
+	passwordUpper   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	passwordLower   = "abcdefghijklmnopqrstuvwxyz"
+	passwordNumeric = "0123456789"
+	passwordSpecial = "!@#$%^&*-_=+"
+)
+
+// generatePassword returns a random password with at least the requested
+// number of characters of each class.
+func generatePassword(rotation *passwordRotation) (string, error) {
+	if rotation.MinUpper+rotation.MinLower+rotation.MinNumeric+rotation.MinSpecial > rotation.Length {
+		return "", fmt.Errorf("the minimum character counts of rotation exceed the password length %d", rotation.Length)
+	}
+
+	var password []byte
+	for _, class := range []struct {
+		chars string
+		min   int
+	}{
+		{passwordUpper, rotation.MinUpper},
+		{passwordLower, rotation.MinLower},
+		{passwordNumeric, rotation.MinNumeric},
+		{passwordSpecial, rotation.MinSpecial},
+	} {
+		for i := 0; i < class.min; i++ {
+			c, err := randomChar(class.chars)
+			if err != nil {
+				return "", err
+			}
+			password = append(password, c)
+		}
+	}
+
+	all := passwordUpper + passwordLower + passwordNumeric + passwordSpecial
+	for len(password) < rotation.Length {
+		c, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", fmt.Errorf("could not generate password: %s", err)
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+
+	return string(password), nil
+}
+
+func randomChar(chars string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+	if err != nil {
+		return 0, fmt.Errorf("could not generate password: %s", err)
+	}
+
+	return chars[n.Int64()], nil
+}
+
 func getNewPasswordInput(newPassword string, passwordFile string) (*string, error) {
 	var newPass string
 	if newPassword != "" {
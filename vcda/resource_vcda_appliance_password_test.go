@@ -27,6 +27,14 @@ func (at *AccTests) TestAccVcdaAppliancePassword_basic(t *testing.T) {
 					resource.TestCheckResourceAttrSet("vcda_appliance_password.appliance_password", "seconds_until_expiration"),
 				),
 			},
+			{
+				Config: testAccVcdaAppliancePasswordConfigRotation(os.Getenv(CloudVMName), "cloud", os.Getenv(VcdaIP)),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vcda_appliance_password.appliance_password", "rotation.0.length", "16"),
+					resource.TestCheckResourceAttr("vcda_appliance_password.appliance_password", "generated_password", ""),
+					resource.TestCheckResourceAttr("vcda_appliance_password.appliance_password", "root_password_expired", "false"),
+				),
+			},
 		},
 	})
 }
@@ -63,3 +71,29 @@ resource "vcda_appliance_password" "appliance_password" {
 		applianceIP,
 	)
 }
+
+func testAccVcdaAppliancePasswordConfigRotation(vmName string, applianceType string, applianceIP string) string {
+	return fmt.Sprintf(`
+data "vcda_service_cert" "service_cert" {
+  datacenter_id = %q
+  name          = %q
+  type          = %q
+}
+
+resource "vcda_appliance_password" "appliance_password" {
+  current_password = %q
+  appliance_ip     = %q
+  service_cert     = data.vcda_service_cert.service_cert.id
+
+  rotation {
+    window_days = 0
+  }
+}
+`,
+		os.Getenv(DatacenterID),
+		vmName,
+		applianceType,
+		os.Getenv(NewPassword),
+		applianceIP,
+	)
+}
@@ -16,20 +16,27 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// cloudDirectorReplicationManagerCreateStepNames are the names of the create
+// steps, in order.
+var cloudDirectorReplicationManagerCreateStepNames = []string{"license", "site", "public endpoint", "Cloud Director", "lookup service"}
+
 func resourceVcdaCloudDirectorReplicationManager() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceCloudDirectorReplicationManagerCreate,
 		ReadContext:   resourceCloudDirectorReplicationManagerRead,
 		UpdateContext: resourceCloudDirectorReplicationManagerUpdate,
 		DeleteContext: resourceCloudDirectorReplicationManagerDelete,
+		CustomizeDiff: resumeCreateStepsCustomizeDiff(cloudDirectorReplicationManagerCreateStepNames),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
 		},
 		Schema: map[string]*schema.Schema{
 			"service_cert": {
-				Type:        schema.TypeString,
-				Description: "The certificate of the Cloud Director Replication Manager Service.",
-				Required:    true,
+				Type: schema.TypeString,
+				Description: "The certificate of the Cloud Director Replication Manager Service. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
 			},
 			"vcd_thumbprint": {
 				Type: schema.TypeString,
@@ -44,10 +51,18 @@ func resourceVcdaCloudDirectorReplicationManager() *schema.Resource {
 				Required: true,
 			},
 			"license_key": {
-				Type:        schema.TypeString,
-				Description: "The license key for VMware Cloud Director Availability.",
-				Required:    true,
-			},
+				Type:         schema.TypeString,
+				Description:  "The license key for VMware Cloud Director Availability.",
+				Optional:     true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"license_key", "license_key_wo"},
+			},
+			"rollback_on_failure":    rollbackOnFailureSchema(),
+			"adopt_existing":         adoptExistingSchema(),
+			"overwrite_existing":     overwriteExistingSchema(),
+			"on_destroy":             onDestroySchema(onDestroyForget, onDestroyForget, onDestroyUnconfigure, onDestroyFactoryReset),
+			"license_key_wo":         writeOnlySchema("license_key", "The license key for VMware Cloud Director Availability."),
+			"license_key_wo_version": writeOnlyVersionSchema("license_key"),
 			"site_name": {
 				Type:        schema.TypeString,
 				Description: "The site name of the Cloud Director Replication Manager.",
@@ -74,10 +89,14 @@ func resourceVcdaCloudDirectorReplicationManager() *schema.Resource {
 				Required:    true,
 			},
 			"vcd_password": {
-				Type:        schema.TypeString,
-				Description: "Cloud Director password.",
-				Required:    true,
-			},
+				Type:         schema.TypeString,
+				Description:  "Cloud Director password.",
+				Optional:     true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"vcd_password", "vcd_password_wo"},
+			},
+			"vcd_password_wo":         writeOnlySchema("vcd_password", "Cloud Director password."),
+			"vcd_password_wo_version": writeOnlyVersionSchema("vcd_password"),
 			"vcd_url": {
 				Type: schema.TypeString,
 				Description: "This is the URL for the Cloud Director API endpoint. " +
@@ -92,6 +111,7 @@ func resourceVcdaCloudDirectorReplicationManager() *schema.Resource {
 			},
 
 			// computed:
+			"completed_steps": completedStepsSchema(),
 			"is_licensed": {
 				Type:        schema.TypeBool,
 				Description: "Flag indicating whether the solution is licensed.",
@@ -206,7 +226,6 @@ func resourceCloudDirectorReplicationManagerCreate(ctx context.Context, d *schem
 	vcdThumbprint := d.Get("vcd_thumbprint").(string)
 	lsThumbprint := d.Get("lookup_service_thumbprint").(string)
 
-	licenseKey := d.Get("license_key").(string)
 	siteName := d.Get("site_name").(string)
 	siteDescription := d.Get("site_description").(string)
 
@@ -214,50 +233,217 @@ func resourceCloudDirectorReplicationManagerCreate(ctx context.Context, d *schem
 	endpointPort := d.Get("public_endpoint_port").(int)
 
 	vcdUsername := d.Get("vcd_username").(string)
-	vcdPassword := d.Get("vcd_password").(string)
 	vcdURL := d.Get("vcd_url").(string)
 
 	lsURL := d.Get("lookup_service_url").(string)
 
-	// set license
-	license, err := c.setLicense(serviceCert, licenseKey)
+	configured, err := c.isConfigured(ctx, serviceCert)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := setLicenseData(d, license); err != nil {
-		return diag.FromErr(err)
+	if configured.IsConfigured {
+		site, err := c.getCloudSiteConfig(ctx, serviceCert)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		endpoints, err := c.getEndpoints(ctx, serviceCert)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		var diffs settingDiffs
+		diffs.add("site_name", site.LocalSite, siteName)
+		diffs.add("site_description", site.LocalSiteDescription, siteDescription)
+		diffs.add("public_endpoint_address", endpoints.Configured.APIPublicAddress, endpointAddress)
+		diffs.add("public_endpoint_port", int(endpoints.Configured.APIPublicPort), endpointPort)
+		diffs.add("vcd_url", strings.TrimSuffix(site.VcdURL, "/api"), vcdURL)
+		diffs.add("vcd_username", site.VcdUsername, vcdUsername)
+		diffs.add("vcd_thumbprint", normalizeThumbprint(site.VcdThumbprint), normalizeThumbprint(vcdThumbprint))
+		diffs.add("lookup_service_url", site.LsURL, lsURL)
+		diffs.add("lookup_service_thumbprint", normalizeThumbprint(site.LsThumbprint), normalizeThumbprint(lsThumbprint))
+
+		diags := checkAdoptable(d, diffs)
+		if diags.HasError() {
+			return diags
+		}
+
+		// an adopted appliance keeps its settings, which the next plan
+		// compares with the configuration
+		if d.Get("adopt_existing").(bool) {
+			if err := diffs.adopt(d); err != nil {
+				return diag.FromErr(err)
+			}
+
+			d.SetId(site.ID)
+			if err := d.Set("completed_steps", cloudDirectorReplicationManagerCreateStepNames); err != nil {
+				return diag.Errorf("error setting completed_steps field: %s", err)
+			}
+
+			return append(diags, resourceCloudDirectorReplicationManagerRead(ctx, d, m)...)
+		}
 	}
 
-	// set site name
-	site, err := c.setCloudSiteName(siteName, siteDescription, serviceCert)
+	steps, err := cloudDirectorReplicationManagerCreateSteps(c, d, configured.IsConfigured)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	// set public API endpoint
-	if err := c.setPublicEndpoint(endpointAddress, endpointPort, serviceCert); err != nil {
-		return diag.FromErr(err)
+	if diags := applyCreateSteps(ctx, d, steps); diags.HasError() {
+		return diags
 	}
 
-	// set vcd
-	vcdThumb := vcdThumbprint
-	if !strings.HasPrefix(vcdThumbprint, "SHA-256:") {
-		vcdThumb = "SHA-256:" + vcdThumbprint
-	}
-	if err := c.setVcloud(vcdUsername, vcdPassword, vcdURL, vcdThumb, serviceCert); err != nil {
+	if err := waitForServiceConfigured(ctx, c, serviceCert, d.Timeout(schema.TimeoutCreate)); err != nil {
 		return diag.FromErr(err)
 	}
 
-	// set cloud lookup service
-	if err := c.setLookupService(lsURL, lsThumbprint, serviceCert); err != nil {
-		return diag.FromErr(err)
+	return resourceCloudDirectorReplicationManagerRead(ctx, d, m)
+}
+
+// cloudDirectorReplicationManagerCreateSteps returns the steps of the create,
+// named as in cloudDirectorReplicationManagerCreateStepNames. configured
+// reports whether the appliance was already configured before the create.
+func cloudDirectorReplicationManagerCreateSteps(c *Client, d *schema.ResourceData, configured bool) ([]createStep, error) {
+	serviceCert := d.Get("service_cert").(string)
+	vcdThumbprint := d.Get("vcd_thumbprint").(string)
+	lsThumbprint := d.Get("lookup_service_thumbprint").(string)
+
+	licenseKey, err := getSecret(d, "license_key")
+	if err != nil {
+		return nil, err
 	}
+	siteName := d.Get("site_name").(string)
+	siteDescription := d.Get("site_description").(string)
+
+	endpointAddress := d.Get("public_endpoint_address").(string)
+	endpointPort := d.Get("public_endpoint_port").(int)
+
+	vcdUsername := d.Get("vcd_username").(string)
+	vcdPassword, err := getSecret(d, "vcd_password")
+	if err != nil {
+		return nil, err
+	}
+	vcdURL := d.Get("vcd_url").(string)
+
+	lsURL := d.Get("lookup_service_url").(string)
+
+	timeout := d.Timeout(schema.TimeoutCreate)
+
+	var priorEndpoint EndpointConfig
+
+	return []createStep{
+		{
+			name: "license",
+			done: func(ctx context.Context) (bool, error) {
+				if !configured {
+					return false, nil
+				}
+
+				license, err := c.getLicense(ctx, serviceCert)
+				if err != nil || !license.IsLicensed {
+					return false, err
+				}
+
+				return true, setLicenseData(d, license)
+			},
+			apply: func(ctx context.Context) error {
+				license, err := c.setLicense(ctx, serviceCert, licenseKey)
+				if err != nil {
+					return err
+				}
+
+				return setLicenseData(d, license)
+			},
+			undo: undoRemoval(c, c.VcdaIP, serviceCert, timeout, "license", func(ctx context.Context) (*Task, error) {
+				return c.removeConfig(ctx, serviceCert, "/license")
+			}),
+		},
+		{
+			name: "site",
+			done: func(ctx context.Context) (bool, error) {
+				site, err := c.getCloudSiteConfig(ctx, serviceCert)
+				if err != nil {
+					return false, err
+				}
+				if site.LocalSite != siteName || site.LocalSiteDescription != siteDescription {
+					return false, nil
+				}
+
+				d.SetId(site.ID)
+				return true, nil
+			},
+			apply: func(ctx context.Context) error {
+				site, err := c.setCloudSiteName(ctx, siteName, siteDescription, serviceCert)
+				if err != nil {
+					return err
+				}
+
+				d.SetId(site.ID)
+				return nil
+			},
+		},
+		{
+			name: "public endpoint",
+			done: func(ctx context.Context) (bool, error) {
+				endpoints, err := c.getEndpoints(ctx, serviceCert)
+				if err != nil {
+					return false, err
+				}
+				priorEndpoint = endpoints.Configured
 
-	d.SetId(site.ID)
+				return priorEndpoint.APIPublicAddress == endpointAddress && priorEndpoint.APIPublicPort == int64(endpointPort), nil
+			},
+			apply: func(ctx context.Context) error {
+				return c.setPublicEndpoint(ctx, endpointAddress, endpointPort, serviceCert)
+			},
+			undo: func(ctx context.Context) error {
+				return c.setPublicEndpoint(ctx, priorEndpoint.APIPublicAddress, int(priorEndpoint.APIPublicPort), serviceCert)
+			},
+		},
+		{
+			name: "Cloud Director",
+			done: func(ctx context.Context) (bool, error) {
+				site, err := c.getCloudSiteConfig(ctx, serviceCert)
+				if err != nil {
+					return false, err
+				}
+
+				return site.VcdURL == vcdURL+"/api" && site.VcdUsername == vcdUsername &&
+					normalizeThumbprint(site.VcdThumbprint) == normalizeThumbprint(vcdThumbprint), nil
+			},
+			apply: func(ctx context.Context) error {
+				return c.setVcloud(ctx, vcdUsername, vcdPassword, vcdURL, sha256Thumbprint(vcdThumbprint), serviceCert)
+			},
+			undo: undoRemoval(c, c.VcdaIP, serviceCert, timeout, "Cloud Director configuration", func(ctx context.Context) (*Task, error) {
+				return c.removeConfig(ctx, serviceCert, "/config/vcloud")
+			}),
+		},
+		{
+			name: "lookup service",
+			done: func(ctx context.Context) (bool, error) {
+				site, err := c.getCloudSiteConfig(ctx, serviceCert)
+				if err != nil {
+					return false, err
+				}
+
+				return site.LsURL == lsURL && normalizeThumbprint(site.LsThumbprint) == normalizeThumbprint(lsThumbprint), nil
+			},
+			apply: func(ctx context.Context) error {
+				return c.setLookupService(ctx, lsURL, lsThumbprint, serviceCert)
+			},
+			undo: undoRemoval(c, c.VcdaIP, serviceCert, timeout, "lookup service configuration", func(ctx context.Context) (*Task, error) {
+				return c.removeConfig(ctx, serviceCert, "/config/lookup-service")
+			}),
+		},
+	}, nil
+}
 
-	err = retry.RetryContext(context.Background(), d.Timeout(schema.TimeoutCreate), func() *retry.RetryError {
-		isConfigured, err := c.isConfigured(serviceCert)
+// waitForServiceConfigured waits for the appliance to report that its
+// service is configured.
+func waitForServiceConfigured(ctx context.Context, c *Client, serviceCert string, timeout time.Duration) error {
+	pollCtx, span := startTaskPollSpan(ctx, "service_configuration", "")
+	err := retry.RetryContext(pollCtx, timeout, func() *retry.RetryError {
+		isConfigured, err := c.isConfigured(pollCtx, serviceCert)
 
 		if err != nil {
 			return retry.NonRetryableError(err)
@@ -269,21 +455,19 @@ func resourceCloudDirectorReplicationManagerCreate(ctx context.Context, d *schem
 
 		return nil
 	})
-	if err != nil {
-		return diag.FromErr(err)
-	}
+	endSpan(span, err)
 
-	return resourceCloudDirectorReplicationManagerRead(ctx, d, m)
+	return err
 }
 
-func resourceCloudDirectorReplicationManagerRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func resourceCloudDirectorReplicationManagerRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 
 	c := m.(*Client)
 
 	serviceCert := d.Get("service_cert").(string)
 
-	vcdaSite, err := c.getCloudSiteConfig(serviceCert)
+	vcdaSite, err := c.getCloudSiteConfig(ctx, serviceCert)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -292,7 +476,7 @@ func resourceCloudDirectorReplicationManagerRead(_ context.Context, d *schema.Re
 		return diag.FromErr(err)
 	}
 
-	endpoints, err := c.getEndpoints(serviceCert)
+	endpoints, err := c.getEndpoints(ctx, serviceCert)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -305,73 +489,135 @@ func resourceCloudDirectorReplicationManagerRead(_ context.Context, d *schema.Re
 }
 
 func resourceCloudDirectorReplicationManagerUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	var diags diag.Diagnostics
 	c := m.(*Client)
 
 	serviceCert := d.Get("service_cert").(string)
 
-	if d.HasChange("license_key") {
-		licenseKey := d.Get("license_key").(string)
-		if licenseKey != "" {
-			vcdaLicense, err := c.setLicense(serviceCert, licenseKey)
-			if err != nil {
-				return diag.FromErr(err)
-			}
-
-			if err := setLicenseData(d, vcdaLicense); err != nil {
-				return diag.FromErr(err)
-			}
-			return resourceCloudDirectorReplicationManagerRead(ctx, d, m)
+	if hasPendingCreateSteps(d.Get("completed_steps").([]interface{}), cloudDirectorReplicationManagerCreateStepNames) {
+		steps, err := cloudDirectorReplicationManagerCreateSteps(c, d, true)
+		if err != nil {
+			return diag.FromErr(err)
 		}
-	}
 
-	if d.HasChange("lookup_service_url") {
-		lsURL := d.Get("lookup_service_url").(string)
-		lsThumbprint := d.Get("lookup_service_thumbprint").(string)
-		if lsURL != "" {
-			if err := c.setLookupService(lsURL, lsThumbprint, serviceCert); err != nil {
-				return diag.FromErr(err)
-			}
-
-			return resourceCloudDirectorReplicationManagerRead(ctx, d, m)
+		if diags := applyCreateSteps(ctx, d, steps); diags.HasError() {
+			return diags
 		}
-	}
 
-	if d.HasChange("vcd_url") || d.HasChange("vcd_password") || d.HasChange("vcd_username") {
-		vcdUsername := d.Get("vcd_username").(string)
-		vcdPassword := d.Get("vcd_password").(string)
-		vcdURL := d.Get("vcd_url").(string)
-		vcdThumbprint := d.Get("vcd_thumbprint").(string)
-
-		if err := c.setVcloud(vcdUsername, vcdPassword, vcdURL, vcdThumbprint, serviceCert); err != nil {
+		if err := waitForServiceConfigured(ctx, c, serviceCert, d.Timeout(schema.TimeoutCreate)); err != nil {
 			return diag.FromErr(err)
 		}
-
-		return resourceCloudDirectorReplicationManagerRead(ctx, d, m)
 	}
 
-	if d.HasChange("public_endpoint_address") || d.HasChange("public_endpoint_port") {
-		endpointAddress := d.Get("public_endpoint_address").(string)
-		endpointPort := d.Get("public_endpoint_port").(int)
+	diags := applyUpdateSteps(ctx, d, []updateStep{
+		{
+			name:    "license",
+			changed: hasSecretChange(d, "license_key"),
+			apply: func(ctx context.Context) error {
+				licenseKey, err := getSecret(d, "license_key")
+				if err != nil || licenseKey == "" {
+					return err
+				}
 
-		if err := c.setPublicEndpoint(endpointAddress, endpointPort, serviceCert); err != nil {
-			return diag.FromErr(err)
-		}
+				license, err := c.setLicense(ctx, serviceCert, licenseKey)
+				if err != nil {
+					return err
+				}
 
-		return resourceCloudDirectorReplicationManagerRead(ctx, d, m)
+				return setLicenseData(d, license)
+			},
+		},
+		{
+			name:    "site",
+			changed: d.HasChanges("site_name", "site_description"),
+			apply: func(ctx context.Context) error {
+				site, err := c.setCloudSiteName(ctx, d.Get("site_name").(string), d.Get("site_description").(string), serviceCert)
+				if err != nil {
+					return err
+				}
+
+				d.SetId(site.ID)
+				return nil
+			},
+		},
+		{
+			name:    "lookup service",
+			changed: d.HasChanges("lookup_service_url", "lookup_service_thumbprint"),
+			apply: func(ctx context.Context) error {
+				return c.setLookupService(ctx, d.Get("lookup_service_url").(string), d.Get("lookup_service_thumbprint").(string), serviceCert)
+			},
+		},
+		{
+			name:    "Cloud Director",
+			changed: d.HasChanges("vcd_url", "vcd_username", "vcd_thumbprint") || hasSecretChange(d, "vcd_password"),
+			apply: func(ctx context.Context) error {
+				vcdPassword, err := getSecret(d, "vcd_password")
+				if err != nil {
+					return err
+				}
+
+				return c.setVcloud(ctx, d.Get("vcd_username").(string), vcdPassword, d.Get("vcd_url").(string),
+					sha256Thumbprint(d.Get("vcd_thumbprint").(string)), serviceCert)
+			},
+		},
+		{
+			name:    "public endpoint",
+			changed: d.HasChanges("public_endpoint_address", "public_endpoint_port"),
+			apply: func(ctx context.Context) error {
+				return c.setPublicEndpoint(ctx, d.Get("public_endpoint_address").(string), d.Get("public_endpoint_port").(int), serviceCert)
+			},
+		},
+	})
+	if diags.HasError() {
+		return diags
 	}
 
-	return diags
+	return resourceCloudDirectorReplicationManagerRead(ctx, d, m)
 }
 
-func resourceCloudDirectorReplicationManagerDelete(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
-	var diags diag.Diagnostics
+func resourceCloudDirectorReplicationManagerDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	serviceCert := d.Get("service_cert").(string)
+
+	diags := destroyAppliance(ctx, d, c, serviceCert, []configRemoval{
+		{
+			name: "Cloud Director configuration",
+			remove: func(ctx context.Context) (*Task, error) {
+				return c.removeConfig(ctx, serviceCert, "/config/vcloud")
+			},
+		},
+		{
+			name: "lookup service configuration",
+			remove: func(ctx context.Context) (*Task, error) {
+				return c.removeConfig(ctx, serviceCert, "/config/lookup-service")
+			},
+		},
+		{
+			name: "license",
+			remove: func(ctx context.Context) (*Task, error) {
+				return c.removeConfig(ctx, serviceCert, "/license")
+			},
+		},
+	})
+	if diags.HasError() {
+		return diags
+	}
 
 	d.SetId("")
 
 	return diags
 }
 
+// sha256Thumbprint returns the thumbprint with the "SHA-256:" prefix that the
+// API expects.
+func sha256Thumbprint(thumbprint string) string {
+	if strings.HasPrefix(thumbprint, thumbprintPrefix) {
+		return thumbprint
+	}
+
+	return thumbprintPrefix + thumbprint
+}
+
 func setCloudSiteData(d *schema.ResourceData, site *CloudSiteConfig) error {
 	if err := d.Set("ls_url", site.LsURL); err != nil {
 		return fmt.Errorf("error setting ls_url field: %s", err)
@@ -28,9 +28,10 @@ func resourceVcdaPairSite() *schema.Resource {
 		},
 		Schema: map[string]*schema.Schema{
 			"service_cert": {
-				Type:        schema.TypeString,
-				Description: "The certificate of the Cloud Director/vCenter Replication Management Appliance.",
-				Required:    true,
+				Type: schema.TypeString,
+				Description: "The certificate of the Cloud Director/vCenter Replication Management Appliance. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
 			},
 			"api_thumbprint": {
 				Type: schema.TypeString,
@@ -107,15 +108,16 @@ func resourcePairSiteCreate(ctx context.Context, d *schema.ResourceData, m inter
 	pairingDescription := d.Get("pairing_description").(string)
 	siteName := d.Get("site").(string)
 
-	taskID, err := c.pairSite(serviceCert, apiThumbprint, apiURL, pairingDescription, siteName)
+	taskID, err := c.pairSite(ctx, serviceCert, apiThumbprint, apiURL, pairingDescription, siteName)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
 	d.SetId(*taskID)
 
-	err = retry.RetryContext(context.Background(), d.Timeout(schema.TimeoutCreate), func() *retry.RetryError {
-		task, err := c.getTask(serviceCert, *taskID)
+	pollCtx, span := startTaskPollSpan(ctx, "pair_site", *taskID)
+	err = retry.RetryContext(pollCtx, d.Timeout(schema.TimeoutCreate), func() *retry.RetryError {
+		task, err := c.getTask(pollCtx, serviceCert, *taskID)
 
 		if err != nil {
 			return retry.NonRetryableError(err)
@@ -129,6 +131,7 @@ func resourcePairSiteCreate(ctx context.Context, d *schema.ResourceData, m inter
 
 		return nil
 	})
+	endSpan(span, err)
 
 	if err != nil {
 		return diag.FromErr(err)
@@ -137,7 +140,7 @@ func resourcePairSiteCreate(ctx context.Context, d *schema.ResourceData, m inter
 	return resourcePairSiteRead(ctx, d, m)
 }
 
-func resourcePairSiteRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func resourcePairSiteRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 	c := m.(*Client)
 
@@ -146,7 +149,7 @@ func resourcePairSiteRead(_ context.Context, d *schema.ResourceData, m interface
 	siteName := d.Get("site").(string)
 
 	if siteName != "" {
-		cloudSite, err := c.getCloudSite(serviceCert, apiURL)
+		cloudSite, err := c.getCloudSite(ctx, serviceCert, apiURL)
 		if err != nil {
 			return diag.FromErr(err)
 		}
@@ -155,7 +158,7 @@ func resourcePairSiteRead(_ context.Context, d *schema.ResourceData, m interface
 			return diag.FromErr(err)
 		}
 	} else {
-		vcenterSite, err := c.getVcenterSite(serviceCert, apiURL)
+		vcenterSite, err := c.getVcenterSite(ctx, serviceCert, apiURL)
 		if err != nil {
 			return diag.FromErr(err)
 		}
@@ -190,15 +193,16 @@ func resourcePairSiteUpdate(ctx context.Context, d *schema.ResourceData, m inter
 		apiURL := d.Get("api_url").(string)
 		pairingDescription := d.Get("pairing_description").(string)
 
-		taskID, err := c.repairSite(serviceCert, site, apiThumbprint, apiURL, pairingDescription)
+		taskID, err := c.repairSite(ctx, serviceCert, site, apiThumbprint, apiURL, pairingDescription)
 		if err != nil {
 			return diag.FromErr(err)
 		}
 
 		d.SetId(*taskID)
 
-		err = retry.RetryContext(context.Background(), d.Timeout(schema.TimeoutUpdate), func() *retry.RetryError {
-			task, err := c.getTask(serviceCert, *taskID)
+		pollCtx, span := startTaskPollSpan(ctx, "repair_site", *taskID)
+		err = retry.RetryContext(pollCtx, d.Timeout(schema.TimeoutUpdate), func() *retry.RetryError {
+			task, err := c.getTask(pollCtx, serviceCert, *taskID)
 
 			if err != nil {
 				return retry.NonRetryableError(err)
@@ -212,6 +216,7 @@ func resourcePairSiteUpdate(ctx context.Context, d *schema.ResourceData, m inter
 
 			return nil
 		})
+		endSpan(span, err)
 
 		if err != nil {
 			return diag.FromErr(err)
@@ -223,7 +228,7 @@ func resourcePairSiteUpdate(ctx context.Context, d *schema.ResourceData, m inter
 	return diags
 }
 
-func resourcePairSiteDelete(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func resourcePairSiteDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 	c := m.(*Client)
 
@@ -240,13 +245,14 @@ func resourcePairSiteDelete(_ context.Context, d *schema.ResourceData, m interfa
 		site = siteName
 	}
 
-	taskID, err := c.unpairSite(serviceCert, site)
+	taskID, err := c.unpairSite(ctx, serviceCert, site)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	err = retry.RetryContext(context.Background(), d.Timeout(schema.TimeoutDelete), func() *retry.RetryError {
-		task, err := c.getTask(serviceCert, *taskID)
+	pollCtx, span := startTaskPollSpan(ctx, "unpair_site", *taskID)
+	err = retry.RetryContext(pollCtx, d.Timeout(schema.TimeoutDelete), func() *retry.RetryError {
+		task, err := c.getTask(pollCtx, serviceCert, *taskID)
 
 		if err != nil {
 			return retry.NonRetryableError(err)
@@ -260,6 +266,7 @@ func resourcePairSiteDelete(_ context.Context, d *schema.ResourceData, m interfa
 
 		return nil
 	})
+	endSpan(span, err)
 
 	if err != nil {
 		return diag.FromErr(err)
@@ -7,23 +7,39 @@ package vcda
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net"
+	"strconv"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
+// defaultDataPort is the default port of the replication (LWD) traffic of a
+// replicator.
+const defaultDataPort = 44045
+
 func resourceVcdaReplicator() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceVcdaReplicatorCreate,
 		ReadContext:   resourceVcdaReplicatorRead,
 		UpdateContext: resourceVcdaReplicatorUpdate,
 		DeleteContext: resourceVcdaReplicatorDelete,
+		CustomizeDiff: resourceVcdaReplicatorCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Update: schema.DefaultTimeout(30 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 		Schema: map[string]*schema.Schema{
 			"service_cert": {
-				Type:        schema.TypeString,
-				Description: "The certificate of the Replicator Service.",
-				Required:    true,
+				Type: schema.TypeString,
+				Description: "The certificate of the Replicator Service. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
 			},
 			"lookup_service_url": {
 				Type: schema.TypeString,
@@ -54,14 +70,78 @@ func resourceVcdaReplicator() *schema.Resource {
 				Required:    true,
 			},
 			"sso_password": {
-				Type:        schema.TypeString,
-				Description: "The password of the SSO user for the Replicator Service.",
-				Required:    true,
+				Type:         schema.TypeString,
+				Description:  "The password of the SSO user for the Replicator Service.",
+				Optional:     true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"sso_password", "sso_password_wo"},
 			},
+			"sso_password_wo":         writeOnlySchema("sso_password", "The password of the SSO user for the Replicator Service."),
+			"sso_password_wo_version": writeOnlyVersionSchema("sso_password"),
 			"root_password": {
-				Type:        schema.TypeString,
-				Description: "The **root** user password of the Replicator Appliance.",
-				Required:    true,
+				Type:         schema.TypeString,
+				Description:  "The **root** user password of the Replicator Appliance.",
+				Optional:     true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"root_password", "root_password_wo"},
+			},
+			"root_password_wo":         writeOnlySchema("root_password", "The **root** user password of the Replicator Appliance."),
+			"root_password_wo_version": writeOnlyVersionSchema("root_password"),
+			"rollback_on_failure":      rollbackOnFailureSchema(),
+			"data_address": {
+				Type: schema.TypeString,
+				Description: "The address on which the Replicator Service receives the replication (LWD) traffic. " +
+					"When not set, the appliance selects the address.",
+				Optional: true,
+				Computed: true,
+			},
+			"data_port": {
+				Type: schema.TypeInt,
+				Description: "The port on which the Replicator Service receives the replication (LWD) traffic. " +
+					"When not set, the port defaults to `" + strconv.Itoa(defaultDataPort) + "`.",
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IsPortNumber,
+			},
+			"maintenance_mode": {
+				Type:        schema.TypeBool,
+				Description: "Whether the Replicator Service is in maintenance mode. A replicator in maintenance mode takes no new replications.",
+				Optional:    true,
+				Default:     false,
+			},
+			"rebalance_replications": {
+				Type: schema.TypeBool,
+				Description: "Whether to move the replications of the Replicator Service to the other replicators of the site " +
+					"when it enters maintenance mode.",
+				Optional: true,
+				Default:  false,
+			},
+			"wait_for_drain": {
+				Type: schema.TypeBool,
+				Description: "Whether entering maintenance mode waits, up to the update timeout, until the rebalanced replications " +
+					"have left the Replicator Service. Requires `rebalance_replications`, since maintenance mode alone does not move replications.",
+				Optional: true,
+				Default:  false,
+			},
+			"force_delete": {
+				Type: schema.TypeBool,
+				Description: "Whether destroying the resource uses the forced removal of the manager, " +
+					"which removes the Replicator Service even when the Replicator Appliance is unreachable.",
+				Optional: true,
+				Default:  false,
+			},
+			"reset_lookup_service_on_destroy": {
+				Type: schema.TypeBool,
+				Description: "Whether destroying the resource also removes the Lookup service registration of the Replicator Appliance. " +
+					"Requires `root_password`, since write-only values are not available on destroy.",
+				Optional: true,
+				Default:  false,
+			},
+			"root_password_revision": {
+				Type: schema.TypeString,
+				Description: "The `root_password_revision` of the `vcda_appliance_password` resource of the Replicator Appliance. " +
+					"When it changes, the Replicator Service is re-registered with the new **root** password.",
+				Optional: true,
 			},
 			"description": {
 				Type:        schema.TypeString,
@@ -80,16 +160,17 @@ func resourceVcdaReplicator() *schema.Resource {
 			},
 
 			// computed
+			"completed_steps": completedStepsSchema(),
+			"active_replications": {
+				Type:        schema.TypeInt,
+				Description: "The number of replications handled by the Replicator Service.",
+				Computed:    true,
+			},
 			"is_in_maintenance_mode": {
 				Type:        schema.TypeBool,
 				Description: "Flag indicating whether the Replicator Service is placed in maintenance mode.",
 				Computed:    true,
 			},
-			"data_address": {
-				Type:        schema.TypeString,
-				Description: "The data address of the Replicator Service.",
-				Computed:    true,
-			},
 			"build_version": {
 				Type:        schema.TypeString,
 				Description: "The build version of the Replicator Service.",
@@ -118,44 +199,130 @@ func resourceVcdaReplicatorCreate(ctx context.Context, d *schema.ResourceData, m
 	lsThumbprint := d.Get("lookup_service_thumbprint").(string)
 	apiURL := d.Get("api_url").(string)
 	apiThumbprint := d.Get("api_thumbprint").(string)
-	rootPassword := d.Get("root_password").(string)
+	rootPassword, err := getSecret(d, "root_password")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	rootPassword = c.rootPasswordFor(apiURL, rootPassword)
 	ssoUser := d.Get("sso_user").(string)
-	ssoPassword := d.Get("sso_password").(string)
+	ssoPassword, err := getSecret(d, "sso_password")
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	description := d.Get("description").(string)
 	owner := d.Get("owner").(string)
 	siteName := d.Get("site_name").(string)
 	host := c.VcdaIP + ":8441"
 
-	// set replicator lookup service
-	replicatorLookupService, err := c.setReplicatorLookupService(host, lsURL, lsThumbprint, apiURL, apiThumbprint, rootPassword, serviceCert)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-	if err := setReplicatorLookupServiceData(d, replicatorLookupService); err != nil {
-		return diag.FromErr(err)
+	details := ReplicatorConfigData{APIURL: apiURL, APIThumbprint: apiThumbprint, RootPassword: rootPassword, SsoUser: ssoUser, SsoPassword: ssoPassword}
+
+	timeout := d.Timeout(schema.TimeoutCreate)
+
+	// the manager registers only a replicator whose Lookup service is
+	// configured, so a registered replicator has every step applied but
+	// possibly its data address
+	registered := func(ctx context.Context) (*Replicator, error) {
+		replicators, err := c.getReplicators(ctx, host, serviceCert)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range replicators {
+			if replicators[i].APIURL == apiURL {
+				return &replicators[i], nil
+			}
+		}
+
+		return nil, nil
 	}
 
-	// add replicator
-	details := ReplicatorConfigData{APIURL: apiURL, APIThumbprint: apiThumbprint, RootPassword: rootPassword, SsoUser: ssoUser, SsoPassword: ssoPassword}
+	steps := []createStep{
+		{
+			name: "lookup service",
+			done: func(ctx context.Context) (bool, error) {
+				replicator, err := registered(ctx)
+				return replicator != nil, err
+			},
+			apply: func(ctx context.Context) error {
+				replicatorLookupService, err := c.setReplicatorLookupService(ctx, host, lsURL, lsThumbprint, apiURL, apiThumbprint, rootPassword, serviceCert)
+				if err != nil {
+					return err
+				}
 
-	replicator, err := c.addReplicator(host, serviceCert, description, owner, siteName, details)
-	if err != nil {
-		return diag.FromErr(err)
+				return setReplicatorLookupServiceData(d, replicatorLookupService)
+			},
+			undo: undoRemoval(c, host, serviceCert, timeout, "replicator lookup service", func(ctx context.Context) (*Task, error) {
+				return c.resetReplicatorLookupService(ctx, host, apiURL, apiThumbprint, rootPassword, serviceCert)
+			}),
+		},
+	}
+	if hasDataAddress(d) {
+		dataAddress := d.Get("data_address").(string)
+		dataPort := replicatorDataPort(d)
+
+		steps = append(steps, createStep{
+			name: "data address",
+			done: func(ctx context.Context) (bool, error) {
+				replicator, err := registered(ctx)
+				if err != nil || replicator == nil {
+					return false, err
+				}
+
+				address, port, ok := replicatorDataAddress(replicator)
+				return ok && address == dataAddress && port == dataPort, nil
+			},
+			apply: func(ctx context.Context) error {
+				return c.setReplicatorDataAddress(ctx, host, dataAddress, dataPort, apiURL, apiThumbprint, rootPassword, serviceCert)
+			},
+		})
+	}
+	steps = append(steps, createStep{
+		name: "replicator",
+		done: func(ctx context.Context) (bool, error) {
+			replicator, err := registered(ctx)
+			if err != nil || replicator == nil {
+				return false, err
+			}
+
+			d.SetId(replicator.ID)
+			return true, nil
+		},
+		apply: func(ctx context.Context) error {
+			replicator, err := c.addReplicator(ctx, host, serviceCert, description, owner, siteName, details)
+			if err != nil {
+				return err
+			}
+
+			d.SetId(replicator.ID)
+			return nil
+		},
+		undo: func(ctx context.Context) error {
+			return c.deleteReplicator(ctx, host, serviceCert, d.Id())
+		},
+	})
+
+	diags := applyCreateSteps(ctx, d, steps)
+	if diags.HasError() {
+		return diags
 	}
 
-	d.SetId(replicator.ID)
+	if d.Get("maintenance_mode").(bool) {
+		if err := c.setReplicatorMaintenanceMode(ctx, host, serviceCert, d.Id(), true); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 
 	return resourceVcdaReplicatorRead(ctx, d, m)
 }
 
-func resourceVcdaReplicatorRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func resourceVcdaReplicatorRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 	c := m.(*Client)
 
 	host := c.VcdaIP + ":8441"
 	serviceCert := d.Get("service_cert").(string)
 
-	replicator, err := c.getReplicator(host, serviceCert, d.Id())
+	replicator, err := c.getReplicator(ctx, host, serviceCert, d.Id())
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -164,8 +331,23 @@ func resourceVcdaReplicatorRead(_ context.Context, d *schema.ResourceData, m int
 		return diag.FromErr(fmt.Errorf("error setting is_in_maintenance_mode field: %s", err))
 	}
 
-	if err := d.Set("data_address", replicator.DataAddress); err != nil {
-		return diag.FromErr(fmt.Errorf("error setting data_address field: %s", err))
+	if err := d.Set("maintenance_mode", replicator.IsInMaintenanceMode); err != nil {
+		return diag.FromErr(fmt.Errorf("error setting maintenance_mode field: %s", err))
+	}
+
+	if err := d.Set("active_replications", replicator.ReplicationsCount); err != nil {
+		return diag.FromErr(fmt.Errorf("error setting active_replications field: %s", err))
+	}
+
+	if dataAddress, dataPort, ok := replicatorDataAddress(replicator); ok {
+
+		if err := d.Set("data_address", dataAddress); err != nil {
+			return diag.FromErr(fmt.Errorf("error setting data_address field: %s", err))
+		}
+
+		if err := d.Set("data_port", dataPort); err != nil {
+			return diag.FromErr(fmt.Errorf("error setting data_port field: %s", err))
+		}
 	}
 
 	if err := d.Set("build_version", replicator.BuildVersion); err != nil {
@@ -176,45 +358,230 @@ func resourceVcdaReplicatorRead(_ context.Context, d *schema.ResourceData, m int
 }
 
 func resourceVcdaReplicatorUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	var diags diag.Diagnostics
 	c := m.(*Client)
 
-	if d.HasChange("root_password") || d.HasChange("sso_user") || d.HasChange("sso_password") {
-		rootPassword := d.Get("root_password").(string)
+	replicatorID := d.Id()
+	serviceCert := d.Get("service_cert").(string)
+	host := c.VcdaIP + ":8441"
+
+	if hasSecretChange(d, "root_password") || d.HasChange("root_password_revision") ||
+		d.HasChange("sso_user") || hasSecretChange(d, "sso_password") {
+		rootPassword, err := getSecret(d, "root_password")
+		if err != nil {
+			return diag.FromErr(err)
+		}
 		ssoUser := d.Get("sso_user").(string)
-		ssoPassword := d.Get("sso_password").(string)
+		ssoPassword, err := getSecret(d, "sso_password")
+		if err != nil {
+			return diag.FromErr(err)
+		}
 
-		replicatorID := d.Id()
 		apiURL := d.Get("api_url").(string)
+		rootPassword = c.rootPasswordFor(apiURL, rootPassword)
 		apiThumbprint := d.Get("api_thumbprint").(string)
-		serviceCert := d.Get("service_cert").(string)
-		host := c.VcdaIP + ":8441"
 
-		if err := c.repairReplicator(host, serviceCert, replicatorID, apiURL, apiThumbprint, rootPassword, ssoUser, ssoPassword); err != nil {
+		if err := c.repairReplicator(ctx, host, serviceCert, replicatorID, apiURL, apiThumbprint, rootPassword, ssoUser, ssoPassword); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.HasChanges("data_address", "data_port") && hasDataAddress(d) {
+		rootPassword, err := getSecret(d, "root_password")
+		if err != nil {
 			return diag.FromErr(err)
 		}
 
-		return resourceVcdaReplicatorRead(ctx, d, m)
+		apiURL := d.Get("api_url").(string)
+		rootPassword = c.rootPasswordFor(apiURL, rootPassword)
+		apiThumbprint := d.Get("api_thumbprint").(string)
+
+		if err := c.setReplicatorDataAddress(ctx, host, d.Get("data_address").(string), replicatorDataPort(d), apiURL, apiThumbprint, rootPassword, serviceCert); err != nil {
+			return diag.FromErr(err)
+		}
 	}
-	return diags
+
+	if d.HasChange("maintenance_mode") {
+		maintenanceMode := d.Get("maintenance_mode").(bool)
+		rebalance := d.Get("rebalance_replications").(bool)
+		waitForDrain := d.Get("wait_for_drain").(bool)
+
+		if err := c.setReplicatorMaintenanceMode(ctx, host, serviceCert, replicatorID, maintenanceMode); err != nil {
+			return diag.FromErr(err)
+		}
+
+		if maintenanceMode {
+			if rebalance {
+				if err := c.rebalanceReplicator(ctx, host, serviceCert, replicatorID); err != nil {
+					return diag.FromErr(err)
+				}
+			}
+
+			if waitForDrain {
+				if err := waitForReplicatorDrain(ctx, c, host, serviceCert, replicatorID, d.Timeout(schema.TimeoutUpdate)); err != nil {
+					return diag.Errorf("the replicator is in maintenance mode, but its replications did not drain: %s", err)
+				}
+			}
+		}
+	}
+
+	return resourceVcdaReplicatorRead(ctx, d, m)
+}
+
+// resourceVcdaReplicatorCustomizeDiff rejects wait_for_drain without
+// rebalance_replications, since maintenance mode alone does not move
+// replications and the wait would only time out.
+func resourceVcdaReplicatorCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if !d.NewValueKnown("rebalance_replications") {
+		return nil
+	}
+
+	if d.Get("maintenance_mode").(bool) && d.Get("wait_for_drain").(bool) && !d.Get("rebalance_replications").(bool) {
+		return fmt.Errorf("wait_for_drain requires rebalance_replications, since maintenance mode alone does not move replications")
+	}
+
+	return nil
+}
+
+// hasDataAddress reports whether the data address or port of the replicator
+// is configured rather than selected by the appliance.
+func hasDataAddress(d *schema.ResourceData) bool {
+	_, hasAddress := d.GetOk("data_address")
+	_, hasPort := d.GetOk("data_port")
+
+	return hasAddress || hasPort
+}
+
+// replicatorDataPort returns the configured data port of the replicator, or
+// the default port.
+func replicatorDataPort(d *schema.ResourceData) int {
+	if port, ok := d.GetOk("data_port"); ok {
+		return port.(int)
+	}
+
+	return defaultDataPort
+}
+
+// replicatorDataAddress returns the host and port on which the replicator
+// receives the replication traffic, and whether the manager reports them.
+// The data address is reported either as an "address:port" string or as an
+// object with an address and a port.
+func replicatorDataAddress(replicator *Replicator) (string, int, bool) {
+	var dataAddress string
+	if err := json.Unmarshal(replicator.DataAddress, &dataAddress); err == nil {
+		if dataAddress == "" {
+			return "", 0, false
+		}
+
+		host, port := splitDataAddress(dataAddress)
+		return host, port, true
+	}
+
+	var endpoint struct {
+		Address string `json:"address"`
+		Port    int    `json:"port"`
+	}
+	if err := json.Unmarshal(replicator.DataAddress, &endpoint); err != nil || endpoint.Address == "" {
+		return "", 0, false
+	}
+
+	if endpoint.Port == 0 {
+		return endpoint.Address, defaultDataPort, true
+	}
+	return endpoint.Address, endpoint.Port, true
+}
+
+// splitDataAddress splits the data address of a replicator, as reported by
+// the manager, into its host and port. An address without a port uses the
+// default port.
+func splitDataAddress(dataAddress string) (string, int) {
+	host, port, err := net.SplitHostPort(dataAddress)
+	if err != nil {
+		return dataAddress, defaultDataPort
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return host, defaultDataPort
+	}
+
+	return host, p
+}
+
+// waitForReplicatorDrain waits until the replicator has no active
+// replications.
+func waitForReplicatorDrain(ctx context.Context, c *Client, host string, serviceCert string, replicatorID string, timeout time.Duration) error {
+	pollCtx, span := startTaskPollSpan(ctx, "replicator_drain", "")
+	err := retry.RetryContext(pollCtx, timeout, func() *retry.RetryError {
+		replicator, err := c.getReplicator(pollCtx, host, serviceCert, replicatorID)
+
+		if err != nil {
+			return retry.NonRetryableError(err)
+		}
+
+		if replicator.ReplicationsCount > 0 {
+			return retry.RetryableError(fmt.Errorf("expected replicator to have no active replications but it has %d", replicator.ReplicationsCount))
+		}
+
+		return nil
+	})
+	endSpan(span, err)
+
+	return err
 }
 
-func resourceVcdaReplicatorDelete(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func resourceVcdaReplicatorDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 	c := m.(*Client)
 
 	host := c.VcdaIP + ":8441"
 	serviceCert := d.Get("service_cert").(string)
 	replicatorID := d.Id()
-
-	if err := c.deleteReplicator(host, serviceCert, replicatorID); err != nil {
+	if d.Get("force_delete").(bool) {
+		task, err := c.forceDeleteReplicator(ctx, host, serviceCert, replicatorID)
+		if err == nil && task != nil {
+			err = waitForTaskOn(ctx, c, host, serviceCert, d.Timeout(schema.TimeoutDelete), "remove replicator", task.ID)
+		}
+		if err != nil {
+			return diag.Errorf("error forcefully removing replicator: %s", err)
+		}
+	} else if err := c.deleteReplicator(ctx, host, serviceCert, replicatorID); err != nil {
 		return diag.FromErr(err)
 	}
+
+	if d.Get("reset_lookup_service_on_destroy").(bool) {
+		// the replicator is already removed, so failing to reset its Lookup
+		// service does not fail the destroy, which could not be retried
+		if err := resetReplicatorLookupService(ctx, c, d, host, serviceCert); err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "could not reset the Lookup service of the replicator",
+				Detail:   fmt.Sprintf("The replicator was removed, but its Lookup service registration is left configured: %s", err),
+			})
+		}
+	}
+
 	d.SetId("")
 
 	return diags
 }
 
+// resetReplicatorLookupService removes the Lookup service registration of the
+// replicator and waits for the task that removes it.
+func resetReplicatorLookupService(ctx context.Context, c *Client, d *schema.ResourceData, host string, serviceCert string) error {
+	apiURL := d.Get("api_url").(string)
+	rootPassword := c.rootPasswordFor(apiURL, d.Get("root_password").(string))
+	if rootPassword == "" {
+		return fmt.Errorf("root_password is not set")
+	}
+
+	task, err := c.resetReplicatorLookupService(ctx, host, apiURL, d.Get("api_thumbprint").(string), rootPassword, serviceCert)
+	if err == nil && task != nil {
+		err = waitForTaskOn(ctx, c, host, serviceCert, d.Timeout(schema.TimeoutDelete), "reset replicator lookup service", task.ID)
+	}
+
+	return err
+}
+
 func setReplicatorLookupServiceData(d *schema.ResourceData, lookupService *LookupService) error {
 	if err := d.Set("replicator_ls_url", lookupService.LsURL); err != nil {
 		return fmt.Errorf("error setting ls_url field: %s", err)
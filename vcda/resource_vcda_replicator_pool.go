@@ -0,0 +1,653 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// The registration statuses of a replicator of a pool.
+const (
+	replicatorRegistered    = "REGISTERED"
+	replicatorNotRegistered = "NOT_REGISTERED"
+)
+
+func resourceVcdaReplicatorPool() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceVcdaReplicatorPoolCreate,
+		ReadContext:   resourceVcdaReplicatorPoolRead,
+		UpdateContext: resourceVcdaReplicatorPoolUpdate,
+		DeleteContext: resourceVcdaReplicatorPoolDelete,
+		CustomizeDiff: resourceVcdaReplicatorPoolCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"service_cert": {
+				Type: schema.TypeString,
+				Description: "The certificate of the Manager Service to which the Replicator Services are added. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
+			},
+			"lookup_service_url": {
+				Type: schema.TypeString,
+				Description: "The URL of the vCenter Server Lookup service. " +
+					"For example, https://server.domain.com/lookupservice/sdk.",
+				Required: true,
+			},
+			"lookup_service_thumbprint": {
+				Type: schema.TypeString,
+				Description: "The thumbprint of the vCenter Server Lookup service. It can either be computed from " +
+					"the `vcda_remote_services_thumbprint` data source or provided directly as a SHA-256 fingerprint.",
+				Required: true,
+			},
+			"sso_user": {
+				Type:        schema.TypeString,
+				Description: "The single sign-on (SSO) user for the Replicator Services.",
+				Required:    true,
+			},
+			"sso_password": {
+				Type:         schema.TypeString,
+				Description:  "The password of the SSO user for the Replicator Services.",
+				Optional:     true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"sso_password", "sso_password_wo"},
+			},
+			"sso_password_wo":         writeOnlySchema("sso_password", "The password of the SSO user for the Replicator Services."),
+			"sso_password_wo_version": writeOnlyVersionSchema("sso_password"),
+			"root_password": {
+				Type: schema.TypeString,
+				Description: "The **root** user password of the Replicator Appliances, " +
+					"for the replicators that do not set their own `root_password`.",
+				Optional:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"root_password_wo"},
+			},
+			"root_password_wo": writeOnlySchema("root_password", "The **root** user password of the Replicator Appliances, "+
+				"for the replicators that do not set their own `root_password`."),
+			"root_password_wo_version": writeOnlyVersionSchema("root_password"),
+			"owner": {
+				Type:        schema.TypeString,
+				Description: "The owner of the Replicator Services.",
+				Required:    true,
+			},
+			"site_name": {
+				Type:        schema.TypeString,
+				Description: "The site name of the Manager Service.",
+				Required:    true,
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Description: "The description for the Replicator Services that do not set their own `description`.",
+				Optional:    true,
+			},
+			"parallelism": {
+				Type:         schema.TypeInt,
+				Description:  "The maximum number of Replicator Services that are added, repaired or removed concurrently.",
+				Optional:     true,
+				Default:      4,
+				ValidateFunc: validation.IntBetween(1, 16),
+			},
+			"replicator": {
+				Type:        schema.TypeList,
+				Description: "The Replicator Services of the pool. Each Replicator Service is identified by its `api_url`.",
+				Required:    true,
+				MinItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"api_url": {
+							Type:        schema.TypeString,
+							Description: "The URL of the Replicator Service API.",
+							Required:    true,
+						},
+						"api_thumbprint": {
+							Type: schema.TypeString,
+							Description: "The thumbprint of the Replicator Service API. It can either be computed from " +
+								"the `vcda_remote_services_thumbprint` data source or provided directly as a SHA-256 fingerprint.",
+							Required: true,
+						},
+						"root_password": {
+							Type:        schema.TypeString,
+							Description: "The **root** user password of the Replicator Appliance. Overrides the `root_password` of the pool.",
+							Optional:    true,
+							Sensitive:   true,
+						},
+						"description": {
+							Type:        schema.TypeString,
+							Description: "The description for the Replicator Service. Overrides the `description` of the pool.",
+							Optional:    true,
+						},
+
+						// computed
+						"id": {
+							Type:        schema.TypeString,
+							Description: "The ID of the Replicator Service instance.",
+							Computed:    true,
+						},
+						"status": {
+							Type: schema.TypeString,
+							Description: "The registration status of the Replicator Service, either `" + replicatorRegistered + "` or `" +
+								replicatorNotRegistered + "`.",
+							Computed: true,
+						},
+						"error": {
+							Type:        schema.TypeString,
+							Description: "The error that prevented the registration of the Replicator Service.",
+							Computed:    true,
+						},
+						"is_in_maintenance_mode": {
+							Type:        schema.TypeBool,
+							Description: "Flag indicating whether the Replicator Service is placed in maintenance mode.",
+							Computed:    true,
+						},
+						"active_replications": {
+							Type:        schema.TypeInt,
+							Description: "The number of replications handled by the Replicator Service.",
+							Computed:    true,
+						},
+						"data_address": {
+							Type:        schema.TypeString,
+							Description: "The address on which the Replicator Service receives the replication traffic.",
+							Computed:    true,
+						},
+						"data_port": {
+							Type:        schema.TypeInt,
+							Description: "The port on which the Replicator Service receives the replication traffic.",
+							Computed:    true,
+						},
+						"build_version": {
+							Type:        schema.TypeString,
+							Description: "The build version of the Replicator Service.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+
+			// computed
+			"registered_count": {
+				Type:        schema.TypeInt,
+				Description: "The number of the Replicator Services of the pool that are registered.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// poolMember is a replicator of a pool.
+type poolMember struct {
+	APIURL        string
+	APIThumbprint string
+	RootPassword  string
+	Description   string
+	ID            string
+	Status        string
+	Error         string
+}
+
+// poolSettings are the settings shared by the replicators of a pool. They are
+// read from the resource data before the replicators are configured
+// concurrently, since the resource data is not safe for concurrent use.
+type poolSettings struct {
+	ServiceCert     string
+	Host            string
+	LsURL           string
+	LsThumbprint    string
+	SsoUser         string
+	SsoPassword     string
+	RootPassword    string
+	Owner           string
+	SiteName        string
+	Description     string
+	Parallelism     int
+	Registered      []Replicator
+	CredentialsDiff bool
+	LsDiff          bool
+}
+
+func expandPoolSettings(c *Client, d *schema.ResourceData) (poolSettings, error) {
+	ssoPassword, err := getSecret(d, "sso_password")
+	if err != nil {
+		return poolSettings{}, err
+	}
+
+	rootPassword, err := getSecret(d, "root_password")
+	if err != nil {
+		return poolSettings{}, err
+	}
+
+	settings := poolSettings{
+		ServiceCert:     d.Get("service_cert").(string),
+		Host:            c.VcdaIP + ":8441",
+		LsURL:           d.Get("lookup_service_url").(string),
+		LsThumbprint:    d.Get("lookup_service_thumbprint").(string),
+		SsoUser:         d.Get("sso_user").(string),
+		SsoPassword:     ssoPassword,
+		RootPassword:    rootPassword,
+		Owner:           d.Get("owner").(string),
+		SiteName:        d.Get("site_name").(string),
+		Description:     d.Get("description").(string),
+		Parallelism:     d.Get("parallelism").(int),
+		CredentialsDiff: hasSecretChange(d, "root_password") || d.HasChange("sso_user") || hasSecretChange(d, "sso_password"),
+		LsDiff:          d.HasChanges("lookup_service_url", "lookup_service_thumbprint"),
+	}
+
+	return settings, nil
+}
+
+func resourceVcdaReplicatorPoolCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*Client)
+
+	settings, err := expandPoolSettings(c, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	members := expandPoolMembers(d.Get("replicator").([]interface{}))
+
+	// the replicators that are already registered, for example by a create
+	// that failed, are adopted instead of being added again
+	settings.Registered, err = c.getReplicators(ctx, settings.Host, settings.ServiceCert)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	errs := forEachPoolMember(settings.Parallelism, len(members), func(i int) error {
+		return registerPoolMember(ctx, c, settings, &members[i])
+	})
+
+	diags = append(diags, poolMemberDiags(members, errs, diag.Warning)...)
+	if countRegistered(members) == 0 {
+		return poolMemberDiags(members, errs, diag.Error)
+	}
+
+	// the replicators that failed to register are reported as warnings,
+	// since an error would taint the pool and unregister the other replicators
+	d.SetId(id.UniqueId())
+
+	if err := setPoolMembers(d, members); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return append(diags, resourceVcdaReplicatorPoolRead(ctx, d, m)...)
+}
+
+func resourceVcdaReplicatorPoolRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*Client)
+
+	serviceCert := d.Get("service_cert").(string)
+	host := c.VcdaIP + ":8441"
+
+	replicators, err := c.getReplicators(ctx, host, serviceCert)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	replicatorsByID := make(map[string]Replicator, len(replicators))
+	for _, replicator := range replicators {
+		replicatorsByID[replicator.ID] = replicator
+	}
+
+	membersData := d.Get("replicator").([]interface{})
+	for _, v := range membersData {
+		memberData := v.(map[string]interface{})
+
+		replicator, ok := replicatorsByID[memberData["id"].(string)]
+		if !ok {
+			if memberData["status"] == replicatorRegistered {
+				memberData["status"] = replicatorNotRegistered
+				memberData["error"] = "The Replicator Service was removed from the Manager Service."
+			}
+			memberData["id"] = ""
+			continue
+		}
+
+		memberData["status"] = replicatorRegistered
+		memberData["error"] = ""
+		memberData["is_in_maintenance_mode"] = replicator.IsInMaintenanceMode
+		memberData["active_replications"] = replicator.ReplicationsCount
+		memberData["build_version"] = ""
+		if replicator.BuildVersion != nil {
+			memberData["build_version"] = fmt.Sprint(replicator.BuildVersion)
+		}
+		memberData["data_address"], memberData["data_port"] = "", 0
+		if dataAddress, dataPort, ok := replicatorDataAddress(&replicator); ok {
+			memberData["data_address"], memberData["data_port"] = dataAddress, dataPort
+		}
+	}
+
+	if err := d.Set("replicator", membersData); err != nil {
+		return diag.FromErr(fmt.Errorf("error setting replicator field: %s", err))
+	}
+
+	if err := d.Set("registered_count", countRegistered(expandPoolMembers(membersData))); err != nil {
+		return diag.FromErr(fmt.Errorf("error setting registered_count field: %s", err))
+	}
+
+	return diags
+}
+
+func resourceVcdaReplicatorPoolUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	settings, err := expandPoolSettings(c, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	o, n := d.GetChange("replicator")
+	oldMembers := expandPoolMembers(o.([]interface{}))
+	members := expandPoolMembers(n.([]interface{}))
+
+	oldByURL := make(map[string]poolMember, len(oldMembers))
+	for _, member := range oldMembers {
+		oldByURL[member.APIURL] = member
+	}
+
+	// the replicators that are no longer in the pool are removed
+	var removed []poolMember
+	for _, member := range oldMembers {
+		if !hasPoolMember(members, member.APIURL) && member.ID != "" {
+			removed = append(removed, member)
+		}
+	}
+
+	settings.Registered, err = c.getReplicators(ctx, settings.Host, settings.ServiceCert)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	errs := forEachPoolMember(settings.Parallelism, len(members), func(i int) error {
+		member := &members[i]
+
+		old, ok := oldByURL[member.APIURL]
+		if !ok || old.ID == "" {
+			return registerPoolMember(ctx, c, settings, member)
+		}
+
+		member.ID = old.ID
+		member.Status = replicatorRegistered
+
+		if settings.LsDiff {
+			if err := setPoolMemberLookupService(ctx, c, settings, *member); err != nil {
+				return err
+			}
+		}
+
+		if settings.CredentialsDiff || member.APIThumbprint != old.APIThumbprint || member.RootPassword != old.RootPassword {
+			return repairPoolMember(ctx, c, settings, *member)
+		}
+
+		return nil
+	})
+	diags := poolMemberDiags(members, errs, diag.Error)
+
+	removeErrs := forEachPoolMember(settings.Parallelism, len(removed), func(i int) error {
+		return c.deleteReplicator(ctx, settings.Host, settings.ServiceCert, removed[i].ID)
+	})
+	for i, err := range removeErrs {
+		if err != nil {
+			// the replicators that failed to be removed are kept in the
+			// state, so that the next apply removes them again
+			members = append(members, removed[i])
+			diags = append(diags, diag.Errorf("error removing replicator %s: %s", removed[i].APIURL, err)...)
+		}
+	}
+
+	if err := setPoolMembers(d, members); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+
+	return append(diags, resourceVcdaReplicatorPoolRead(ctx, d, m)...)
+}
+
+func resourceVcdaReplicatorPoolDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*Client)
+
+	serviceCert := d.Get("service_cert").(string)
+	host := c.VcdaIP + ":8441"
+
+	members := expandPoolMembers(d.Get("replicator").([]interface{}))
+
+	errs := forEachPoolMember(d.Get("parallelism").(int), len(members), func(i int) error {
+		if members[i].ID == "" {
+			return nil
+		}
+
+		return c.deleteReplicator(ctx, host, serviceCert, members[i].ID)
+	})
+	for i, err := range errs {
+		if err != nil {
+			diags = append(diags, diag.Errorf("error removing replicator %s: %s", members[i].APIURL, err)...)
+		}
+	}
+	if diags.HasError() {
+		return diags
+	}
+
+	d.SetId("")
+
+	return diags
+}
+
+// resourceVcdaReplicatorPoolCustomizeDiff plans the registration of the
+// replicators that are not registered, so that the next apply retries them.
+func resourceVcdaReplicatorPoolCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	seen := make(map[string]bool)
+	for _, member := range expandPoolMembers(d.Get("replicator").([]interface{})) {
+		if member.APIURL == "" {
+			continue
+		}
+		if seen[member.APIURL] {
+			return fmt.Errorf("replicator %s is declared more than once", member.APIURL)
+		}
+		seen[member.APIURL] = true
+	}
+
+	if d.Id() == "" {
+		return nil
+	}
+
+	o, _ := d.GetChange("replicator")
+	if oldMembers := expandPoolMembers(o.([]interface{})); countRegistered(oldMembers) < len(oldMembers) {
+		return d.SetNewComputed("registered_count")
+	}
+
+	return nil
+}
+
+// registerPoolMember configures the Lookup service of the replicator and adds
+// it to the manager, unless a replicator with the same API URL is already
+// registered.
+func registerPoolMember(ctx context.Context, c *Client, settings poolSettings, member *poolMember) error {
+	member.Status = replicatorNotRegistered
+
+	for _, replicator := range settings.Registered {
+		if replicator.APIURL == member.APIURL {
+			member.ID = replicator.ID
+			member.Status = replicatorRegistered
+			return nil
+		}
+	}
+
+	if err := setPoolMemberLookupService(ctx, c, settings, *member); err != nil {
+		return err
+	}
+
+	details, err := poolMemberConfig(c, settings, *member)
+	if err != nil {
+		return err
+	}
+
+	description := member.Description
+	if description == "" {
+		description = settings.Description
+	}
+
+	replicator, err := c.addReplicator(ctx, settings.Host, settings.ServiceCert, description, settings.Owner, settings.SiteName, details)
+	if err != nil {
+		return err
+	}
+
+	member.ID = replicator.ID
+	member.Status = replicatorRegistered
+
+	return nil
+}
+
+func setPoolMemberLookupService(ctx context.Context, c *Client, settings poolSettings, member poolMember) error {
+	details, err := poolMemberConfig(c, settings, member)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.setReplicatorLookupService(ctx, settings.Host, settings.LsURL, settings.LsThumbprint,
+		details.APIURL, details.APIThumbprint, details.RootPassword, settings.ServiceCert)
+
+	return err
+}
+
+func repairPoolMember(ctx context.Context, c *Client, settings poolSettings, member poolMember) error {
+	details, err := poolMemberConfig(c, settings, member)
+	if err != nil {
+		return err
+	}
+
+	return c.repairReplicator(ctx, settings.Host, settings.ServiceCert, member.ID, details.APIURL, details.APIThumbprint,
+		details.RootPassword, details.SsoUser, details.SsoPassword)
+}
+
+// poolMemberConfig returns the configuration of the replicator, with the
+// shared settings of the pool.
+func poolMemberConfig(c *Client, settings poolSettings, member poolMember) (ReplicatorConfigData, error) {
+	rootPassword := member.RootPassword
+	if rootPassword == "" {
+		rootPassword = settings.RootPassword
+	}
+	if rootPassword == "" {
+		return ReplicatorConfigData{}, fmt.Errorf("neither the replicator nor the pool sets root_password")
+	}
+
+	config := ReplicatorConfigData{
+		APIURL:        member.APIURL,
+		APIThumbprint: member.APIThumbprint,
+		RootPassword:  c.rootPasswordFor(member.APIURL, rootPassword),
+		SsoUser:       settings.SsoUser,
+		SsoPassword:   settings.SsoPassword,
+	}
+
+	return config, nil
+}
+
+// forEachPoolMember calls fn for the replicators 0 to n-1 concurrently, with
+// at most parallelism calls at a time, and returns their errors by index. fn
+// must not use the resource data.
+func forEachPoolMember(parallelism int, n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	sem := make(chan struct{}, parallelism)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// poolMemberDiags records the errors of the replicators and returns them as
+// diagnostics of the given severity.
+func poolMemberDiags(members []poolMember, errs []error, severity diag.Severity) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		members[i].Error = err.Error()
+		diags = append(diags, diag.Diagnostic{
+			Severity: severity,
+			Summary:  "error configuring replicator " + members[i].APIURL,
+			Detail:   err.Error(),
+		})
+	}
+
+	return diags
+}
+
+func expandPoolMembers(membersData []interface{}) []poolMember {
+	members := make([]poolMember, 0, len(membersData))
+	for _, v := range membersData {
+		memberData, _ := v.(map[string]interface{})
+		member := poolMember{}
+		member.APIURL, _ = memberData["api_url"].(string)
+		member.APIThumbprint, _ = memberData["api_thumbprint"].(string)
+		member.RootPassword, _ = memberData["root_password"].(string)
+		member.Description, _ = memberData["description"].(string)
+		member.ID, _ = memberData["id"].(string)
+		member.Status, _ = memberData["status"].(string)
+		member.Error, _ = memberData["error"].(string)
+		members = append(members, member)
+	}
+
+	return members
+}
+
+func setPoolMembers(d *schema.ResourceData, members []poolMember) error {
+	membersData := make([]interface{}, 0, len(members))
+	for _, member := range members {
+		membersData = append(membersData, map[string]interface{}{
+			"api_url":        member.APIURL,
+			"api_thumbprint": member.APIThumbprint,
+			"root_password":  member.RootPassword,
+			"description":    member.Description,
+			"id":             member.ID,
+			"status":         member.Status,
+			"error":          member.Error,
+		})
+	}
+
+	if err := d.Set("replicator", membersData); err != nil {
+		return fmt.Errorf("error setting replicator field: %s", err)
+	}
+
+	return nil
+}
+
+func hasPoolMember(members []poolMember, apiURL string) bool {
+	for _, member := range members {
+		if member.APIURL == apiURL {
+			return true
+		}
+	}
+
+	return false
+}
+
+func countRegistered(members []poolMember) int {
+	count := 0
+	for _, member := range members {
+		if member.Status == replicatorRegistered {
+			count++
+		}
+	}
+
+	return count
+}
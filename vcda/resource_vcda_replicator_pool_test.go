@@ -0,0 +1,173 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func (at *AccTests) TestAccVcdaReplicatorPool_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testAccVcdaReplicatorPreCheck(t)
+		},
+		ProviderFactories: testProviders(),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVcdaReplicatorPoolConfigBasic(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vcda_replicator_pool.pool", "registered_count", "1"),
+					resource.TestCheckResourceAttr("vcda_replicator_pool.pool", "replicator.0.status", replicatorRegistered),
+					resource.TestCheckResourceAttrSet("vcda_replicator_pool.pool", "replicator.0.id"),
+					resource.TestCheckResourceAttrSet("vcda_replicator_pool.pool", "replicator.0.build_version"),
+				),
+			},
+		},
+	})
+}
+
+func testAccVcdaReplicatorPoolConfigBasic() string {
+	return fmt.Sprintf(`
+
+variable "datacenter_id" {
+  type    = string
+  default = %q
+}
+
+data "vcda_service_cert" "manager_service_cert" {
+  datacenter_id = var.datacenter_id
+  name          = %q
+  type          = "manager"
+}
+
+data "vcda_remote_services_thumbprint" "ls_thumbprint" {
+  address      = %q
+  port         = "443"
+}
+
+data "vcda_remote_services_thumbprint" "replicator_thumbprint" {
+  address      = %q
+  port         = "443"
+}
+
+resource "vcda_replicator_pool" "pool" {
+  lookup_service_url = %q
+  sso_user           = %q
+  sso_password       = %q
+  root_password      = %q
+  owner              = "*"
+  site_name          = "manager-site1"
+
+  service_cert              = data.vcda_service_cert.manager_service_cert.id
+  lookup_service_thumbprint = data.vcda_remote_services_thumbprint.ls_thumbprint.id
+
+  replicator {
+    api_url        = %q
+    api_thumbprint = data.vcda_remote_services_thumbprint.replicator_thumbprint.id
+  }
+}
+`,
+		os.Getenv(DatacenterID),
+		os.Getenv(ManagerVMName),
+		os.Getenv(LookupServiceAddress),
+		os.Getenv(ReplicatorAddress),
+		"https://"+os.Getenv(LookupServiceAddress)+":443/lookupservice/sdk",
+		os.Getenv(SsoUser),
+		os.Getenv(SsoPassword),
+		os.Getenv(RootPassword),
+		"https://"+os.Getenv(ReplicatorAddress)+":8043",
+	)
+}
+
+// TestVcdaReplicatorPool_forEachPoolMember configures the replicators of a
+// pool concurrently from resource data that has a diff. Run it with -race.
+func (at *AccTests) TestVcdaReplicatorPool_forEachPoolMember(t *testing.T) {
+	membersData := make([]interface{}, 12)
+	for i := range membersData {
+		membersData[i] = map[string]interface{}{
+			"api_url":        fmt.Sprintf("https://replicator-%d:8043", i),
+			"api_thumbprint": "SHA-256:00",
+		}
+	}
+	d := schema.TestResourceDataRaw(t, resourceVcdaReplicatorPool().Schema, map[string]interface{}{
+		"lookup_service_url":        "https://lookup:443/lookupservice/sdk",
+		"lookup_service_thumbprint": "SHA-256:00",
+		"sso_user":                  "administrator@vsphere.local",
+		"sso_password":              "sso-password",
+		"root_password":             "root-password",
+		"owner":                     "*",
+		"site_name":                 "site1",
+		"parallelism":               3,
+		"replicator":                membersData,
+	})
+
+	c := &Client{}
+	settings, err := expandPoolSettings(c, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := expandPoolMembers(d.Get("replicator").([]interface{}))
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	configs := make([]ReplicatorConfigData, len(members))
+	errs := forEachPoolMember(settings.Parallelism, len(members), func(i int) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if i == 4 {
+			return fmt.Errorf("replicator unreachable")
+		}
+
+		var err error
+		configs[i], err = poolMemberConfig(c, settings, members[i])
+		return err
+	})
+	if maxRunning > 3 {
+		t.Errorf("expected at most 3 concurrent calls, got %d", maxRunning)
+	}
+	if configs[11].RootPassword != "root-password" || configs[11].SsoPassword != "sso-password" {
+		t.Errorf("expected the shared settings of the pool, got %+v", configs[11])
+	}
+
+	for i := range members {
+		members[i].Status = replicatorRegistered
+	}
+	members[4].Status = replicatorNotRegistered
+
+	diags := poolMemberDiags(members, errs, diag.Warning)
+	if len(diags) != 1 || diags[0].Severity != diag.Warning || members[4].Error != "replicator unreachable" {
+		t.Errorf("expected the failed replicator to be reported: %v", diags)
+	}
+	if got := countRegistered(members); got != 11 {
+		t.Errorf("expected 11 registered replicators, got %d", got)
+	}
+
+	if err := setPoolMembers(d, members); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(expandPoolMembers(d.Get("replicator").([]interface{}))), fmt.Sprint(members); got != want {
+		t.Errorf("expected the replicators %s, got %s", want, got)
+	}
+}
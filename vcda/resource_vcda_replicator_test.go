@@ -6,6 +6,8 @@
 package vcda
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"os"
@@ -29,7 +31,7 @@ func (at *AccTests) TestAccVcdaReplicator_basic(t *testing.T) {
 				),
 			},
 			{
-				Config: testAccVcdaReplicatorConfigBasic(),
+				Config: testAccVcdaReplicatorConfigBasic(false),
 				Check: resource.ComposeTestCheckFunc(
 					resource.TestCheckResourceAttr("vcda_replicator.add_replicator", "is_in_maintenance_mode", "false"),
 					resource.TestCheckResourceAttr("vcda_replicator.add_replicator",
@@ -40,6 +42,19 @@ func (at *AccTests) TestAccVcdaReplicator_basic(t *testing.T) {
 					resource.TestCheckResourceAttrSet("vcda_replicator.add_replicator", "replicator_ls_thumbprint"),
 				),
 			},
+			{
+				Config: testAccVcdaReplicatorConfigBasic(true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vcda_replicator.add_replicator", "is_in_maintenance_mode", "true"),
+					resource.TestCheckResourceAttr("vcda_replicator.add_replicator", "active_replications", "0"),
+				),
+			},
+			{
+				Config: testAccVcdaReplicatorConfigBasic(false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vcda_replicator.add_replicator", "is_in_maintenance_mode", "false"),
+				),
+			},
 		},
 	})
 }
@@ -75,7 +90,7 @@ func testAccVcdaReplicatorPreCheck(t *testing.T) {
 	}
 }
 
-func testAccVcdaReplicatorConfigBasic() string {
+func testAccVcdaReplicatorConfigBasic(maintenanceMode bool) string {
 	return fmt.Sprintf(`
 
 variable "datacenter_id" {
@@ -107,6 +122,7 @@ resource "vcda_replicator" "add_replicator" {
   root_password      = %q
   owner              = "*"
   site_name          = "manager-site1"
+  maintenance_mode   = %t
 
   api_thumbprint            = data.vcda_remote_services_thumbprint.replicator_thumbprint.id
   service_cert              = data.vcda_service_cert.manager_service_cert.id
@@ -122,5 +138,49 @@ resource "vcda_replicator" "add_replicator" {
 		os.Getenv(SsoUser),
 		os.Getenv(SsoPassword),
 		os.Getenv(RootPassword),
+		maintenanceMode,
 	)
 }
+
+// TestVcdaReplicator_dataAddress reads the data address of a replicator,
+// which the manager reports either as a string or as an object.
+func (at *AccTests) TestVcdaReplicator_dataAddress(t *testing.T) {
+	for dataAddress, want := range map[string]string{
+		`"10.0.0.5:44045"`:                    "10.0.0.5 44045 true",
+		`"10.0.0.5:46000"`:                    "10.0.0.5 46000 true",
+		`"[fd00::5]:46000"`:                   "fd00::5 46000 true",
+		`"10.0.0.5"`:                          "10.0.0.5 44045 true",
+		`{"address":"10.0.0.5","port":46000}`: "10.0.0.5 46000 true",
+		`{"address":"10.0.0.5"}`:              "10.0.0.5 44045 true",
+		`null`:                                " 0 false",
+		``:                                    " 0 false",
+	} {
+		host, port, ok := replicatorDataAddress(&Replicator{DataAddress: json.RawMessage(dataAddress)})
+		if got := fmt.Sprint(host, " ", port, " ", ok); got != want {
+			t.Errorf("data address %s: expected %s, got %s", dataAddress, want, got)
+		}
+	}
+
+	var replicator Replicator
+	if err := json.Unmarshal([]byte(`{"id":"replicator","dataAddress":{"address":"10.0.0.5","port":46000}}`), &replicator); err != nil {
+		t.Fatalf("expected an object data address to be decoded: %s", err)
+	}
+}
+
+// TestVcdaReplicator_forceDelete checks the defaults of the destroy options
+// and that the Lookup service reset requires the root password.
+func (at *AccTests) TestVcdaReplicator_forceDelete(t *testing.T) {
+	d := resourceVcdaReplicator().TestResourceData()
+	if err := d.Set("api_url", "https://replicator:8043"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := resetReplicatorLookupService(context.Background(), &Client{}, d, "manager:8441", "")
+	if err == nil || err.Error() != "root_password is not set" {
+		t.Errorf("expected the reset to require the root password, got %v", err)
+	}
+
+	if d.Get("force_delete").(bool) || d.Get("reset_lookup_service_on_destroy").(bool) {
+		t.Error("expected the forced removal and the Lookup service reset to be disabled by default")
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// trafficSettingsID is the ID of the traffic settings, of which each appliance
+// has exactly one.
+const trafficSettingsID = "traffic-settings"
+
+var (
+	weekdays = []string{"MONDAY", "TUESDAY", "WEDNESDAY", "THURSDAY", "FRIDAY", "SATURDAY", "SUNDAY"}
+
+	timeOfDay = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+)
+
+func resourceVcdaTrafficSettings() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceVcdaTrafficSettingsCreate,
+		ReadContext:   resourceVcdaTrafficSettingsRead,
+		UpdateContext: resourceVcdaTrafficSettingsUpdate,
+		DeleteContext: resourceVcdaTrafficSettingsDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"service_cert": {
+				Type: schema.TypeString,
+				Description: "The certificate of the Replication Manager Service of the site. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
+			},
+			"incoming_limit_mbps": trafficLimitSchema("The bandwidth limit of the incoming replication traffic of the site, in Mbps."),
+			"outgoing_limit_mbps": trafficLimitSchema("The bandwidth limit of the outgoing replication traffic of the site, in Mbps."),
+			"schedule": {
+				Type: schema.TypeList,
+				Description: "The bandwidth limits that apply during a time window instead of `incoming_limit_mbps` and `outgoing_limit_mbps`. " +
+					"The times are in the time zone of the appliance.",
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"days": {
+							Type:        schema.TypeSet,
+							Description: "The days on which the schedule applies, any of `MONDAY` to `SUNDAY`.",
+							Required:    true,
+							MinItems:    1,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validation.StringInSlice(weekdays, false),
+							},
+						},
+						"start_time": {
+							Type:         schema.TypeString,
+							Description:  "The start time of the schedule, in the `HH:MM` format.",
+							Required:     true,
+							ValidateFunc: validation.StringMatch(timeOfDay, "must be in the HH:MM format"),
+						},
+						"end_time": {
+							Type:         schema.TypeString,
+							Description:  "The end time of the schedule, in the `HH:MM` format.",
+							Required:     true,
+							ValidateFunc: validation.StringMatch(timeOfDay, "must be in the HH:MM format"),
+						},
+						"incoming_limit_mbps": trafficLimitSchema("The bandwidth limit of the incoming replication traffic during the schedule, in Mbps."),
+						"outgoing_limit_mbps": trafficLimitSchema("The bandwidth limit of the outgoing replication traffic during the schedule, in Mbps."),
+					},
+				},
+			},
+			"on_destroy": onDestroySchema(onDestroyUnconfigure, onDestroyForget, onDestroyUnconfigure),
+		},
+	}
+}
+
+// trafficLimitSchema returns the schema of a bandwidth limit, where 0 is
+// unlimited.
+func trafficLimitSchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeInt,
+		Description:  description + " `0` is unlimited.",
+		Optional:     true,
+		Default:      0,
+		ValidateFunc: validation.IntAtLeast(0),
+	}
+}
+
+func resourceVcdaTrafficSettingsCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	serviceCert := d.Get("service_cert").(string)
+
+	if _, err := c.setTrafficSettings(ctx, expandTrafficSettings(d), serviceCert); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(trafficSettingsID)
+
+	return resourceVcdaTrafficSettingsRead(ctx, d, m)
+}
+
+func resourceVcdaTrafficSettingsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*Client)
+
+	serviceCert := d.Get("service_cert").(string)
+
+	trafficSettings, err := c.getTrafficSettings(ctx, serviceCert)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := setTrafficSettingsData(d, trafficSettings); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diags
+}
+
+func resourceVcdaTrafficSettingsUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*Client)
+
+	serviceCert := d.Get("service_cert").(string)
+
+	if d.HasChanges("incoming_limit_mbps", "outgoing_limit_mbps", "schedule") {
+		if _, err := c.setTrafficSettings(ctx, expandTrafficSettings(d), serviceCert); err != nil {
+			return diag.FromErr(err)
+		}
+
+		return resourceVcdaTrafficSettingsRead(ctx, d, m)
+	}
+	return diags
+}
+
+func resourceVcdaTrafficSettingsDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	serviceCert := d.Get("service_cert").(string)
+
+	diags := destroyAppliance(ctx, d, c, serviceCert, []configRemoval{
+		{
+			name: "traffic settings",
+			remove: func(ctx context.Context) (*Task, error) {
+				return c.removeConfig(ctx, serviceCert, "/config/traffic-settings")
+			},
+		},
+	})
+	if diags.HasError() {
+		return diags
+	}
+
+	d.SetId("")
+
+	return diags
+}
+
+func expandTrafficSettings(d *schema.ResourceData) TrafficSettings {
+	trafficSettings := TrafficSettings{
+		IncomingLimitMbps: d.Get("incoming_limit_mbps").(int),
+		OutgoingLimitMbps: d.Get("outgoing_limit_mbps").(int),
+		Schedules:         []TrafficSchedule{},
+	}
+
+	for _, v := range d.Get("schedule").([]interface{}) {
+		schedule := v.(map[string]interface{})
+
+		var days []string
+		for _, day := range schedule["days"].(*schema.Set).List() {
+			days = append(days, day.(string))
+		}
+		sort.Strings(days)
+
+		trafficSettings.Schedules = append(trafficSettings.Schedules, TrafficSchedule{
+			Days:              days,
+			StartTime:         schedule["start_time"].(string),
+			EndTime:           schedule["end_time"].(string),
+			IncomingLimitMbps: schedule["incoming_limit_mbps"].(int),
+			OutgoingLimitMbps: schedule["outgoing_limit_mbps"].(int),
+		})
+	}
+
+	return trafficSettings
+}
+
+func setTrafficSettingsData(d *schema.ResourceData, trafficSettings *TrafficSettings) error {
+	if err := d.Set("incoming_limit_mbps", trafficSettings.IncomingLimitMbps); err != nil {
+		return fmt.Errorf("error setting incoming_limit_mbps field: %s", err)
+	}
+
+	if err := d.Set("outgoing_limit_mbps", trafficSettings.OutgoingLimitMbps); err != nil {
+		return fmt.Errorf("error setting outgoing_limit_mbps field: %s", err)
+	}
+
+	schedules := make([]interface{}, 0, len(trafficSettings.Schedules))
+	for _, schedule := range trafficSettings.Schedules {
+		schedules = append(schedules, map[string]interface{}{
+			"days":                schedule.Days,
+			"start_time":          schedule.StartTime,
+			"end_time":            schedule.EndTime,
+			"incoming_limit_mbps": schedule.IncomingLimitMbps,
+			"outgoing_limit_mbps": schedule.OutgoingLimitMbps,
+		})
+	}
+
+	if err := d.Set("schedule", schedules); err != nil {
+		return fmt.Errorf("error setting schedule field: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"os"
+	"testing"
+)
+
+func (at *AccTests) TestAccVcdaTrafficSettings_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testAccVcdaCloudHealthPreCheck(t)
+		},
+		ProviderFactories: testProviders(),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVcdaTrafficSettingsConfigBasic(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vcda_traffic_settings.traffic", "incoming_limit_mbps", "1000"),
+					resource.TestCheckResourceAttr("vcda_traffic_settings.traffic", "outgoing_limit_mbps", "0"),
+					resource.TestCheckResourceAttr("vcda_traffic_settings.traffic", "schedule.0.start_time", "08:00"),
+					resource.TestCheckResourceAttr("vcda_traffic_settings.traffic", "schedule.0.days.#", "5"),
+				),
+			},
+		},
+	})
+}
+
+func testAccVcdaTrafficSettingsConfigBasic() string {
+	return fmt.Sprintf(`
+data "vcda_service_cert" "cloud_service_cert" {
+  datacenter_id = %q
+  name          = %q
+  type          = "cloud"
+}
+
+resource "vcda_traffic_settings" "traffic" {
+  service_cert = data.vcda_service_cert.cloud_service_cert.id
+
+  incoming_limit_mbps = 1000
+
+  schedule {
+    days                = ["MONDAY", "TUESDAY", "WEDNESDAY", "THURSDAY", "FRIDAY"]
+    start_time          = "08:00"
+    end_time            = "18:00"
+    outgoing_limit_mbps = 100
+  }
+}
+`,
+		os.Getenv(DatacenterID),
+		os.Getenv(CloudVMName),
+	)
+}
+
+// TestVcdaTrafficSettings_expandTrafficSettings reads back the traffic
+// settings that are set in the state.
+func (at *AccTests) TestVcdaTrafficSettings_expandTrafficSettings(t *testing.T) {
+	d := resourceVcdaTrafficSettings().TestResourceData()
+	trafficSettings := &TrafficSettings{
+		IncomingLimitMbps: 1000,
+		Schedules: []TrafficSchedule{
+			{Days: []string{"FRIDAY", "MONDAY"}, StartTime: "08:00", EndTime: "18:00", OutgoingLimitMbps: 100},
+		},
+	}
+	if err := setTrafficSettingsData(d, trafficSettings); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(expandTrafficSettings(d)), fmt.Sprint(*trafficSettings); got != want {
+		t.Errorf("expected the traffic settings %s, got %s", want, got)
+	}
+}
@@ -6,11 +6,19 @@
 package vcda
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceVcdaTunnel() *schema.Resource {
@@ -19,12 +27,18 @@ func resourceVcdaTunnel() *schema.Resource {
 		ReadContext:   resourceVcdaTunnelRead,
 		UpdateContext: resourceVcdaTunnelUpdate,
 		DeleteContext: resourceVcdaTunnelDelete,
+		CustomizeDiff: resourceVcdaTunnelCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Schema: map[string]*schema.Schema{
 			"service_cert": {
 				Type: schema.TypeString,
 				Description: "The service certificate of the Cloud Director Replication Management Service " +
-					"to which the Tunnel Service is being added.",
-				Required: true,
+					"to which the Tunnel Service is being added. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
 			},
 			"url": {
 				Type:        schema.TypeString,
@@ -32,14 +46,59 @@ func resourceVcdaTunnel() *schema.Resource {
 				Required:    true,
 			},
 			"certificate": {
-				Type:        schema.TypeString,
-				Description: "The certificate of the Tunnel Service.",
-				Required:    true,
+				Type:         schema.TypeString,
+				Description:  "The certificate of the Tunnel Service. When it changes, the Tunnel Service is re-registered with the new certificate.",
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"certificate", "tunnel_vm"},
+			},
+			"tunnel_vm": {
+				Type: schema.TypeList,
+				Description: "The Tunnel Appliance VM to read the certificate of the Tunnel Service from, instead of `certificate`. " +
+					"The certificate is read from the `" + TunnelCertExtraConfigKey + "` extraConfig of the VM on every plan, " +
+					"so that a regenerated certificate is registered automatically.",
+				Optional:     true,
+				MaxItems:     1,
+				ExactlyOneOf: []string{"certificate", "tunnel_vm"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Description: "The VM name or inventory path of the Tunnel Appliance.",
+							Required:    true,
+						},
+						"datacenter_id": {
+							Type: schema.TypeString,
+							Description: "The managed object ID of the datacenter where the VM resides in. " +
+								"When not set, the default datacenter is used.",
+							Optional: true,
+						},
+					},
+				},
 			},
 			"root_password": {
-				Type:        schema.TypeString,
-				Description: "The **root** user password of the Tunnel Appliance.",
-				Required:    true,
+				Type:         schema.TypeString,
+				Description:  "The **root** user password of the Tunnel Appliance.",
+				Optional:     true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"root_password", "root_password_wo"},
+			},
+			"root_password_wo":         writeOnlySchema("root_password", "The **root** user password of the Tunnel Appliance."),
+			"root_password_wo_version": writeOnlyVersionSchema("root_password"),
+			"on_destroy":               onDestroySchema(onDestroyUnconfigure, onDestroyForget, onDestroyUnconfigure),
+			"priority": {
+				Type: schema.TypeInt,
+				Description: "The priority of the Tunnel Service among the tunnels of the Cloud Director Replication Management Appliance. " +
+					"The tunnels with lower values are preferred. When not set, the appliance assigns the priority.",
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"root_password_revision": {
+				Type: schema.TypeString,
+				Description: "The `root_password_revision` of the `vcda_appliance_password` resource of the Tunnel Appliance. " +
+					"When it changes, the Tunnel Service is re-registered with the new **root** password.",
+				Optional: true,
 			},
 
 			// computed
@@ -64,9 +123,12 @@ func resourceVcdaTunnelCreate(ctx context.Context, d *schema.ResourceData, m int
 
 	URL := d.Get("url").(string)
 	certificate := d.Get("certificate").(string)
-	rootPassword := d.Get("root_password").(string)
+	rootPassword, err := getSecret(d, "root_password")
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	tunnelConfig, err := c.setTunnel(URL, certificate, rootPassword, serviceCert)
+	tunnelConfig, err := c.setTunnel(ctx, URL, certificate, c.rootPasswordFor(URL, rootPassword), tunnelPriority(d), serviceCert)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -76,21 +138,59 @@ func resourceVcdaTunnelCreate(ctx context.Context, d *schema.ResourceData, m int
 	return resourceVcdaTunnelRead(ctx, d, m)
 }
 
-func resourceVcdaTunnelRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func resourceVcdaTunnelRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 	c := m.(*Client)
 
 	serviceCert := d.Get("service_cert").(string)
 
-	tunnel, err := c.getTunnelConfig(serviceCert, d.Id())
+	tunnel, err := c.getTunnelConfig(ctx, serviceCert, d.Id())
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
+	if tunnel == nil {
+		tflog.Warn(ctx, "Tunnel was removed from the manager, removing it from the state", map[string]interface{}{"id": d.Id()})
+		d.SetId("")
+		return diags
+	}
+
 	if err := setTunnelData(d, tunnel); err != nil {
 		return diag.FromErr(err)
 	}
 
+	vm, hasVM := tunnelVM(d.Get("tunnel_vm"))
+
+	var liveCertificate string
+	if hasVM {
+		if liveCertificate, err = tunnelVMCertificate(ctx, c, vm); err != nil {
+			return diag.FromErr(err)
+		}
+	} else if liveCertificate, err = tunnelLiveCertificate(ctx, c.APITimeout, tunnel.URL); err != nil {
+		// an unreachable tunnel does not fail the refresh, which reports
+		// its connectivity through the vcda_tunnels data source
+		tflog.Warn(ctx, "Could not fetch the live certificate of the tunnel", map[string]interface{}{"url": tunnel.URL, "error": err.Error()})
+	}
+
+	// the certificate registered in the manager is stored as the current
+	// certificate, so that a plan with the live one re-registers it
+	if liveCertificate != "" && !sameCertificate(tunnel.Certificate, liveCertificate) {
+		if err := d.Set("certificate", tunnel.Certificate); err != nil {
+			return diag.Errorf("error setting certificate field: %s", err)
+		}
+
+		detail := "The next apply re-registers the Tunnel Service with the live certificate."
+		if !hasVM {
+			detail = "Set certificate to the live certificate, or use tunnel_vm, to re-register the Tunnel Service."
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "tunnel certificate drift",
+			Detail: fmt.Sprintf("The certificate of Tunnel Service %s registered in the manager differs from its live certificate. %s",
+				tunnel.URL, detail),
+		})
+	}
+
 	return diags
 }
 
@@ -100,25 +200,53 @@ func resourceVcdaTunnelUpdate(ctx context.Context, d *schema.ResourceData, m int
 
 	serviceCert := d.Get("service_cert").(string)
 
-	if d.HasChange("url") || d.HasChange("root_password") {
+	if d.HasChanges("url", "certificate", "priority", "root_password_revision") || hasSecretChange(d, "root_password") {
 		URL := d.Get("url").(string)
 		certificate := d.Get("certificate").(string)
-		rootPassword := d.Get("root_password").(string)
+		rootPassword, err := getSecret(d, "root_password")
+		if err != nil {
+			return diag.FromErr(err)
+		}
 
-		tunnelConfig, err := c.setTunnel(URL, certificate, rootPassword, serviceCert)
+		tunnelConfig, err := c.setTunnel(ctx, URL, certificate, c.rootPasswordFor(URL, rootPassword), tunnelPriority(d), serviceCert)
 		if err != nil {
 			return diag.FromErr(err)
 		}
 
-		d.SetId(tunnelConfig.ID)
+		// a new URL adds another tunnel to the manager, so the tunnel with the
+		// old URL is removed once the new one is registered
+		if oldID := d.Id(); tunnelConfig.ID != oldID {
+			task, err := c.deleteTunnel(ctx, serviceCert, oldID)
+			if err == nil && task != nil {
+				err = waitForTask(ctx, c, serviceCert, d.Timeout(schema.TimeoutUpdate), "remove tunnel", task.ID)
+			}
+			d.SetId(tunnelConfig.ID)
+			if err != nil {
+				return diag.Errorf("error removing the tunnel with the previous URL: %s", err)
+			}
+		}
 
 		return resourceVcdaTunnelRead(ctx, d, m)
 	}
 	return diags
 }
 
-func resourceVcdaTunnelDelete(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
-	var diags diag.Diagnostics
+func resourceVcdaTunnelDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	serviceCert := d.Get("service_cert").(string)
+
+	diags := destroyAppliance(ctx, d, c, serviceCert, []configRemoval{
+		{
+			name: "tunnel registration",
+			remove: func(ctx context.Context) (*Task, error) {
+				return c.deleteTunnel(ctx, serviceCert, d.Id())
+			},
+		},
+	})
+	if diags.HasError() {
+		return diags
+	}
 
 	d.SetId("")
 
@@ -134,5 +262,108 @@ func setTunnelData(d *schema.ResourceData, tunnelConfig *TunnelConfig) error {
 		return fmt.Errorf("error setting tunnel_certificate field: %s", err)
 	}
 
+	if err := d.Set("priority", tunnelConfig.Priority); err != nil {
+		return fmt.Errorf("error setting priority field: %s", err)
+	}
+
+	return nil
+}
+
+// resourceVcdaTunnelCustomizeDiff plans the certificate of the tunnel VM, when
+// set, so that a regenerated certificate is registered.
+func resourceVcdaTunnelCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	vm, ok := tunnelVM(d.Get("tunnel_vm"))
+	if !ok {
+		return nil
+	}
+
+	certificate, err := tunnelVMCertificate(ctx, m.(*Client), vm)
+	if err != nil {
+		return err
+	}
+
+	if !sameCertificate(d.Get("certificate").(string), certificate) {
+		return d.SetNew("certificate", certificate)
+	}
+
+	return nil
+}
+
+// tunnelVM returns the tunnel_vm block, if set.
+func tunnelVM(v interface{}) (map[string]interface{}, bool) {
+	vms, _ := v.([]interface{})
+	if len(vms) == 0 || vms[0] == nil {
+		return nil, false
+	}
+
+	return vms[0].(map[string]interface{}), true
+}
+
+// tunnelVMCertificate reads the tunnel certificate from the extraConfig of the
+// tunnel VM.
+func tunnelVMCertificate(ctx context.Context, c *Client, vm map[string]interface{}) (string, error) {
+	serviceCert, err := lookupServiceCert(ctx, c, ServiceCertLookup{
+		Name:         vm["name"].(string),
+		DatacenterID: vm["datacenter_id"].(string),
+		Type:         "tunnel",
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not read the certificate of the tunnel VM: %s", err)
+	}
+
+	return serviceCert.Certificate, nil
+}
+
+// tunnelLiveCertificate fetches the certificate that the Tunnel Service at
+// tunnelURL presents, base64-encoded DER as in certificate.
+func tunnelLiveCertificate(ctx context.Context, timeout time.Duration, tunnelURL string) (string, error) {
+	u, err := url.Parse(tunnelURL)
+	if err != nil {
+		return "", fmt.Errorf("could not parse tunnel URL: %s", err)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	chain, err := fetchPeerCertificates(ctx, u.Hostname(), port, "")
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(chain[0].Raw), nil
+}
+
+// sameCertificate reports whether the certificates are equal, whether they are
+// PEM-encoded or base64-encoded DER.
+func sameCertificate(a string, b string) bool {
+	return bytes.Equal(certificateDER(a), certificateDER(b))
+}
+
+func certificateDER(cert string) []byte {
+	if block, _ := pem.Decode([]byte(cert)); block != nil {
+		return block.Bytes
+	}
+
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(cert), ""))
+	if err != nil {
+		return []byte(cert)
+	}
+
+	return der
+}
+
+// tunnelPriority returns the configured priority of the tunnel, or nil to let
+// the appliance assign it.
+func tunnelPriority(d *schema.ResourceData) *int {
+	if priority, ok := d.GetOk("priority"); ok {
+		p := priority.(int)
+		return &p
+	}
+
 	return nil
 }
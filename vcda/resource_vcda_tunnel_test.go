@@ -6,10 +6,16 @@
 package vcda
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 )
 
 func (at *AccTests) TestAccVcdaTunnel_basic(t *testing.T) {
@@ -35,6 +41,14 @@ func (at *AccTests) TestAccVcdaTunnel_basic(t *testing.T) {
 					resource.TestCheckResourceAttrSet("vcda_tunnel.add_tunnel", "tunnel_certificate"),
 				),
 			},
+			{
+				Config: testAccVcdaTunnelConfigWriteOnly(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckNoResourceAttr("vcda_tunnel.add_tunnel", "root_password"),
+					resource.TestCheckNoResourceAttr("vcda_tunnel.add_tunnel", "root_password_wo"),
+					resource.TestCheckResourceAttr("vcda_tunnel.add_tunnel", "root_password_wo_version", "1"),
+				),
+			},
 		},
 	})
 }
@@ -89,3 +103,70 @@ resource "vcda_tunnel" "add_tunnel" {
 		os.Getenv(RootPassword),
 	)
 }
+
+func testAccVcdaTunnelConfigWriteOnly() string {
+	return fmt.Sprintf(`
+
+variable "datacenter_id" {
+  type    = string
+  default = %q
+}
+
+data "vcda_service_cert" "cloud_service_cert" {
+  datacenter_id = var.datacenter_id
+  name          = %q
+  type          = "cloud"
+}
+
+data "vcda_service_cert" "tunnel_service_cert" {
+  datacenter_id = var.datacenter_id
+  name          = %q
+  type          = "tunnel"
+}
+
+resource "vcda_tunnel" "add_tunnel" {
+  service_cert = data.vcda_service_cert.cloud_service_cert.id
+
+  url                      = %q
+  root_password_wo         = %q
+  root_password_wo_version = 1
+  certificate              = data.vcda_service_cert.tunnel_service_cert.id
+}
+`,
+		os.Getenv(DatacenterID),
+		os.Getenv(CloudVMName),
+		os.Getenv(TunnelVMName),
+		"https://"+os.Getenv(TunnelAddress)+":8047",
+		os.Getenv(RootPassword),
+	)
+}
+
+// TestVcdaTunnel_sameCertificate compares tunnel certificates regardless of
+// their encoding.
+func (at *AccTests) TestVcdaTunnel_sameCertificate(t *testing.T) {
+	der := []byte("tunnel certificate")
+	encoded := base64.StdEncoding.EncodeToString(der)
+	pemEncoded := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	if !sameCertificate(encoded, pemEncoded) {
+		t.Error("expected the base64 and PEM encodings of a certificate to be the same")
+	}
+	if sameCertificate(encoded, base64.StdEncoding.EncodeToString([]byte("regenerated certificate"))) {
+		t.Error("expected different certificates to differ")
+	}
+}
+
+// TestVcdaTunnel_liveCertificate fetches the certificate that a Tunnel
+// Service presents at its URL.
+func (at *AccTests) TestVcdaTunnel_liveCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	certificate, err := tunnelLiveCertificate(context.Background(), time.Minute, server.URL)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !sameCertificate(certificate, base64.StdEncoding.EncodeToString(server.Certificate().Raw)) {
+		t.Error("expected the certificate presented by the Tunnel Service")
+	}
+}
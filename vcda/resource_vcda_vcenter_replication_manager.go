@@ -8,22 +8,32 @@ package vcda
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// vcenterReplicationManagerCreateStepNames are the names of the create steps,
+// in order.
+var vcenterReplicationManagerCreateStepNames = []string{"license", "site", "lookup service", "vSphere plugin"}
+
 func resourceVcdaVcenterReplicationManager() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceVcenterReplicationManagerCreate,
 		ReadContext:   resourceVcenterReplicationManagerRead,
 		UpdateContext: resourceVcenterReplicationManagerUpdate,
 		DeleteContext: resourceVcenterReplicationManagerDelete,
+		CustomizeDiff: resumeCreateStepsCustomizeDiff(vcenterReplicationManagerCreateStepNames),
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Schema: map[string]*schema.Schema{
 			"service_cert": {
-				Type:        schema.TypeString,
-				Description: "The service certificate of the vCenter Replication Manager.",
-				Required:    true,
+				Type: schema.TypeString,
+				Description: "The service certificate of the vCenter Replication Manager. " +
+					"When not set, the certificate is discovered from the appliance VM or the provider `appliance` settings.",
+				Optional: true,
 			},
 			"lookup_service_thumbprint": {
 				Type: schema.TypeString,
@@ -32,10 +42,18 @@ func resourceVcdaVcenterReplicationManager() *schema.Resource {
 				Required: true,
 			},
 			"license_key": {
-				Type:        schema.TypeString,
-				Description: "The license key of VMware Cloud Director Availability.",
-				Required:    true,
+				Type:         schema.TypeString,
+				Description:  "The license key of VMware Cloud Director Availability.",
+				Optional:     true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"license_key", "license_key_wo"},
 			},
+			"license_key_wo":         writeOnlySchema("license_key", "The license key of VMware Cloud Director Availability."),
+			"license_key_wo_version": writeOnlyVersionSchema("license_key"),
+			"rollback_on_failure":    rollbackOnFailureSchema(),
+			"adopt_existing":         adoptExistingSchema(),
+			"overwrite_existing":     overwriteExistingSchema(),
+			"on_destroy":             onDestroySchema(onDestroyForget, onDestroyForget, onDestroyUnconfigure, onDestroyFactoryReset),
 			"site_name": {
 				Type:        schema.TypeString,
 				Description: "The site name of the vCenter Replication Manager.",
@@ -53,12 +71,17 @@ func resourceVcdaVcenterReplicationManager() *schema.Resource {
 				Required:    true,
 			},
 			"sso_password": {
-				Type:        schema.TypeString,
-				Description: "The password of the SSO administrator.",
-				Required:    true,
+				Type:         schema.TypeString,
+				Description:  "The password of the SSO administrator.",
+				Optional:     true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"sso_password", "sso_password_wo"},
 			},
+			"sso_password_wo":         writeOnlySchema("sso_password", "The password of the SSO administrator."),
+			"sso_password_wo_version": writeOnlyVersionSchema("sso_password"),
 
 			// computed:
+			"completed_steps": completedStepsSchema(),
 			"is_licensed": {
 				Type:        schema.TypeBool,
 				Description: "Flag indicating whether the service is licensed.",
@@ -108,54 +131,170 @@ func resourceVcenterReplicationManagerCreate(ctx context.Context, d *schema.Reso
 	c := m.(*Client)
 
 	serviceCert := d.Get("service_cert").(string)
-	licenseKey := d.Get("license_key").(string)
 	siteName := d.Get("site_name").(string)
 	lsURL := d.Get("lookup_service_url").(string)
 	lsThumbprint := d.Get("lookup_service_thumbprint").(string)
-	ssoUser := d.Get("sso_user").(string)
-	ssoPassword := d.Get("sso_password").(string)
 
-	// set license
-	license, err := c.setLicense(serviceCert, licenseKey)
+	configured, err := c.isConfigured(ctx, serviceCert)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := setLicenseData(d, license); err != nil {
-		return diag.FromErr(err)
+	if configured.IsConfigured {
+		site, err := c.getManagerSiteConfig(ctx, serviceCert)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		var diffs settingDiffs
+		diffs.add("site_name", site.Site, siteName)
+		diffs.add("lookup_service_url", site.LsURL, lsURL)
+		diffs.add("lookup_service_thumbprint", normalizeThumbprint(site.LsThumbprint), normalizeThumbprint(lsThumbprint))
+
+		diags := checkAdoptable(d, diffs)
+		if diags.HasError() {
+			return diags
+		}
+
+		// an adopted appliance keeps its settings, which the next plan
+		// compares with the configuration
+		if d.Get("adopt_existing").(bool) {
+			if err := diffs.adopt(d); err != nil {
+				return diag.FromErr(err)
+			}
+
+			d.SetId(site.ID)
+			if err := d.Set("completed_steps", vcenterReplicationManagerCreateStepNames); err != nil {
+				return diag.Errorf("error setting completed_steps field: %s", err)
+			}
+
+			return append(diags, resourceVcenterReplicationManagerRead(ctx, d, m)...)
+		}
 	}
 
-	// set site name
-	site, err := c.setSiteName(siteName, serviceCert)
+	steps, err := vcenterReplicationManagerCreateSteps(c, d, configured.IsConfigured)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	// set manager lookup service
-	if err := c.setManagerLookupService(lsURL, lsThumbprint, ssoUser, ssoPassword, serviceCert); err != nil {
-		return diag.FromErr(err)
+	if diags := applyCreateSteps(ctx, d, steps); diags.HasError() {
+		return diags
 	}
 
-	pluginStatus, err := c.setVspherePlugin(serviceCert)
+	return resourceVcenterReplicationManagerRead(ctx, d, m)
+}
+
+// vcenterReplicationManagerCreateSteps returns the steps of the create, named
+// as in vcenterReplicationManagerCreateStepNames. configured reports whether
+// the appliance was already configured before the create.
+func vcenterReplicationManagerCreateSteps(c *Client, d *schema.ResourceData, configured bool) ([]createStep, error) {
+	serviceCert := d.Get("service_cert").(string)
+	licenseKey, err := getSecret(d, "license_key")
 	if err != nil {
-		return diag.FromErr(err)
+		return nil, err
 	}
-
-	if err := d.Set("vsphere_plugin_status", pluginStatus.Status); err != nil {
-		return diag.Errorf("error setting vsphere_plugin_status field: %s", err)
+	siteName := d.Get("site_name").(string)
+	lsURL := d.Get("lookup_service_url").(string)
+	lsThumbprint := d.Get("lookup_service_thumbprint").(string)
+	ssoUser := d.Get("sso_user").(string)
+	ssoPassword, err := getSecret(d, "sso_password")
+	if err != nil {
+		return nil, err
 	}
 
-	d.SetId(site.ID)
+	timeout := d.Timeout(schema.TimeoutCreate)
 
-	return resourceVcenterReplicationManagerRead(ctx, d, m)
+	return []createStep{
+		{
+			name: "license",
+			done: func(ctx context.Context) (bool, error) {
+				if !configured {
+					return false, nil
+				}
+
+				license, err := c.getLicense(ctx, serviceCert)
+				if err != nil || !license.IsLicensed {
+					return false, err
+				}
+
+				return true, setLicenseData(d, license)
+			},
+			apply: func(ctx context.Context) error {
+				license, err := c.setLicense(ctx, serviceCert, licenseKey)
+				if err != nil {
+					return err
+				}
+
+				return setLicenseData(d, license)
+			},
+			undo: undoRemoval(c, c.VcdaIP, serviceCert, timeout, "license", func(ctx context.Context) (*Task, error) {
+				return c.removeConfig(ctx, serviceCert, "/license")
+			}),
+		},
+		{
+			name: "site",
+			done: func(ctx context.Context) (bool, error) {
+				site, err := c.getManagerSiteConfig(ctx, serviceCert)
+				if err != nil || site.Site != siteName {
+					return false, err
+				}
+
+				d.SetId(site.ID)
+				return true, nil
+			},
+			apply: func(ctx context.Context) error {
+				site, err := c.setSiteName(ctx, siteName, serviceCert)
+				if err != nil {
+					return err
+				}
+
+				d.SetId(site.ID)
+				return nil
+			},
+		},
+		{
+			name: "lookup service",
+			done: func(ctx context.Context) (bool, error) {
+				site, err := c.getManagerSiteConfig(ctx, serviceCert)
+				if err != nil {
+					return false, err
+				}
+
+				return site.LsURL == lsURL && normalizeThumbprint(site.LsThumbprint) == normalizeThumbprint(lsThumbprint), nil
+			},
+			apply: func(ctx context.Context) error {
+				return c.setManagerLookupService(ctx, lsURL, lsThumbprint, ssoUser, ssoPassword, serviceCert)
+			},
+			undo: undoRemoval(c, c.VcdaIP, serviceCert, timeout, "lookup service configuration", func(ctx context.Context) (*Task, error) {
+				return c.removeConfig(ctx, serviceCert, "/config/lookup-service")
+			}),
+		},
+		{
+			name: "vSphere plugin",
+			apply: func(ctx context.Context) error {
+				pluginStatus, err := c.setVspherePlugin(ctx, serviceCert)
+				if err != nil {
+					return err
+				}
+
+				if err := d.Set("vsphere_plugin_status", pluginStatus.Status); err != nil {
+					return fmt.Errorf("error setting vsphere_plugin_status field: %s", err)
+				}
+				return nil
+			},
+			undo: func(ctx context.Context) error {
+				return c.removeVspherePlugin(ctx, serviceCert)
+			},
+		},
+	}, nil
 }
 
-func resourceVcenterReplicationManagerRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func resourceVcenterReplicationManagerRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*Client)
 
 	serviceCert := d.Get("service_cert").(string)
 
-	managerSite, err := c.getManagerSiteConfig(serviceCert)
+	managerSite, err := c.getManagerSiteConfig(ctx, serviceCert)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -172,47 +311,98 @@ func resourceVcenterReplicationManagerUpdate(ctx context.Context, d *schema.Reso
 
 	serviceCert := d.Get("service_cert").(string)
 
-	if d.HasChange("license_key") {
-		licenseKey := d.Get("license_key").(string)
-		if licenseKey != "" {
-			license, err := c.setLicense(serviceCert, licenseKey)
-			if err != nil {
-				return diag.FromErr(err)
-			}
+	if hasPendingCreateSteps(d.Get("completed_steps").([]interface{}), vcenterReplicationManagerCreateStepNames) {
+		steps, err := vcenterReplicationManagerCreateSteps(c, d, true)
+		if err != nil {
+			return diag.FromErr(err)
+		}
 
-			if err := setLicenseData(d, license); err != nil {
-				return diag.FromErr(err)
-			}
-			return resourceVcenterReplicationManagerRead(ctx, d, m)
+		if diags := applyCreateSteps(ctx, d, steps); diags.HasError() {
+			return diags
 		}
 	}
 
-	if d.HasChange("lookup_service_url") {
-		lsURL := d.Get("lookup_service_url").(string)
-		lsThumbprint := d.Get("lookup_service_thumbprint").(string)
-		if lsURL != "" {
-			if err := c.setLookupService(lsURL, lsThumbprint, serviceCert); err != nil {
-				return diag.FromErr(err)
-			}
-
-			return resourceVcenterReplicationManagerRead(ctx, d, m)
-		}
+	diags := applyUpdateSteps(ctx, d, []updateStep{
+		{
+			name:    "license",
+			changed: hasSecretChange(d, "license_key"),
+			apply: func(ctx context.Context) error {
+				licenseKey, err := getSecret(d, "license_key")
+				if err != nil || licenseKey == "" {
+					return err
+				}
+
+				license, err := c.setLicense(ctx, serviceCert, licenseKey)
+				if err != nil {
+					return err
+				}
+
+				return setLicenseData(d, license)
+			},
+		},
+		{
+			name:    "site",
+			changed: d.HasChange("site_name"),
+			apply: func(ctx context.Context) error {
+				site, err := c.setSiteName(ctx, d.Get("site_name").(string), serviceCert)
+				if err != nil {
+					return err
+				}
+
+				d.SetId(site.ID)
+				return nil
+			},
+		},
+		{
+			name:    "lookup service",
+			changed: d.HasChanges("lookup_service_url", "lookup_service_thumbprint", "sso_user") || hasSecretChange(d, "sso_password"),
+			apply: func(ctx context.Context) error {
+				ssoPassword, err := getSecret(d, "sso_password")
+				if err != nil {
+					return err
+				}
+
+				return c.setManagerLookupService(ctx, d.Get("lookup_service_url").(string), d.Get("lookup_service_thumbprint").(string),
+					d.Get("sso_user").(string), ssoPassword, serviceCert)
+			},
+		},
+	})
+	if diags.HasError() {
+		return diags
 	}
 
 	return resourceVcenterReplicationManagerRead(ctx, d, m)
 }
 
-func resourceVcenterReplicationManagerDelete(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func resourceVcenterReplicationManagerDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 
 	c := m.(*Client)
 
 	serviceCert := d.Get("service_cert").(string)
 
-	if err := c.removeVspherePlugin(serviceCert); err != nil {
+	if err := c.removeVspherePlugin(ctx, serviceCert); err != nil {
 		return diag.FromErr(err)
 	}
 
+	diags = destroyAppliance(ctx, d, c, serviceCert, []configRemoval{
+		{
+			name: "lookup service configuration",
+			remove: func(ctx context.Context) (*Task, error) {
+				return c.removeConfig(ctx, serviceCert, "/config/lookup-service")
+			},
+		},
+		{
+			name: "license",
+			remove: func(ctx context.Context) (*Task, error) {
+				return c.removeConfig(ctx, serviceCert, "/license")
+			},
+		},
+	})
+	if diags.HasError() {
+		return diags
+	}
+
 	d.SetId("")
 
 	return diags
@@ -0,0 +1,238 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi/vim25/soap"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// tracerName is the instrumentation scope of the provider spans.
+	tracerName = "terraform-provider-for-vmware-cloud-director-availability"
+	// tracingServiceName is the service name of the exported spans, unless
+	// set with OTEL_SERVICE_NAME.
+	tracingServiceName = "terraform-provider-vcda"
+)
+
+// The span attributes of the provider operations.
+const (
+	attrResourceType = attribute.Key("vcda.resource_type")
+	attrResourceID   = attribute.Key("vcda.resource_id")
+	attrAppliance    = attribute.Key("vcda.appliance")
+	attrTaskID       = attribute.Key("vcda.task_id")
+	attrTaskType     = attribute.Key("vcda.task_type")
+)
+
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// InitTracing sets up the OpenTelemetry tracing of the provider operations.
+// Spans are written as JSON lines to the file named by the VCDA_TRACES_FILE
+// environment variable, or else exported with OTLP when the standard
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+// environment variable is set. Tracing is disabled otherwise. The returned
+// function flushes the pending spans and must be called before exiting.
+func InitTracing(ctx context.Context) (func(context.Context) error, error) {
+	exporter, closeExporter, err := newSpanExporter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not set up tracing: %s", err)
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(tracingServiceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not set up tracing: %s", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeErr := closeExporter(); err == nil {
+			err = closeErr
+		}
+		return err
+	}, nil
+}
+
+// newSpanExporter returns the exporter selected by the environment, or nil
+// when tracing is disabled, and a function that releases its resources.
+func newSpanExporter(ctx context.Context) (sdktrace.SpanExporter, func() error, error) {
+	noClose := func() error { return nil }
+
+	if name := os.Getenv(TracesFile); name != "" {
+		f, err := os.OpenFile(filepath.Clean(name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not open traces file: %s", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return nil, nil, err
+		}
+		return exporter, f.Close, nil
+	}
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return nil, noClose, nil
+	}
+
+	protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+	if protocol == "" {
+		protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	if protocol == "grpc" {
+		exporter, err = otlptracegrpc.New(ctx)
+	} else {
+		exporter, err = otlptracehttp.New(ctx)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return exporter, noClose, nil
+}
+
+// endSpan records err, if any, and ends span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceResources wraps the CRUD functions of the resources and data sources
+// with spans named after their type and operation.
+func traceResources(resources map[string]*schema.Resource) {
+	for typeName, r := range resources {
+		r.CreateContext = traceCRUD(typeName, "Create", r.CreateContext)
+		r.ReadContext = traceCRUD(typeName, "Read", r.ReadContext)
+		r.UpdateContext = traceCRUD(typeName, "Update", r.UpdateContext)
+		r.DeleteContext = traceCRUD(typeName, "Delete", r.DeleteContext)
+	}
+}
+
+func traceCRUD(typeName string, operation string, f func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+	if f == nil {
+		return nil
+	}
+
+	return func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+		ctx, span := tracer().Start(ctx, typeName+"."+operation, trace.WithAttributes(
+			attrResourceType.String(typeName),
+			attrResourceID.String(d.Id()),
+		))
+		defer span.End()
+
+		diags := f(ctx, d, m)
+		for _, diagnostic := range diags {
+			if diagnostic.Severity == diag.Error {
+				span.SetStatus(codes.Error, diagnostic.Summary)
+				break
+			}
+		}
+
+		return diags
+	}
+}
+
+// startTaskPollSpan starts the span of a loop that waits for a VCDA task.
+func startTaskPollSpan(ctx context.Context, taskType string, taskID string) (context.Context, trace.Span) {
+	attributes := []attribute.KeyValue{attrTaskType.String(taskType)}
+	if taskID != "" {
+		attributes = append(attributes, attrTaskID.String(taskID))
+	}
+
+	return tracer().Start(ctx, "wait "+taskType, trace.WithAttributes(attributes...))
+}
+
+// tracingTransport traces the VCDA API requests.
+type tracingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := tracer().Start(req.Context(), "VCDA "+req.Method+" "+req.URL.Path,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLPath(req.URL.Path),
+			semconv.ServerAddress(req.URL.Hostname()),
+			attrAppliance.String(req.URL.Host),
+		))
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if !successCheck(resp.StatusCode) {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	span.End()
+
+	return resp, nil
+}
+
+// tracingSoapRoundTripper traces the vSphere API calls.
+type tracingSoapRoundTripper struct {
+	next   soap.RoundTripper
+	server string
+}
+
+func (rt *tracingSoapRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	method := reflect.TypeOf(req).String()
+	method = strings.TrimSuffix(method[strings.LastIndex(method, ".")+1:], "Body")
+
+	ctx, span := tracer().Start(ctx, "vSphere "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("vsphere"),
+			semconv.RPCMethod(method),
+			semconv.ServerAddress(rt.server),
+		))
+
+	err := rt.next.RoundTrip(ctx, req, res)
+	endSpan(span, err)
+
+	return err
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2023-2024 Broadcom. All Rights Reserved.
+// Broadcom Confidential. The term "Broadcom" refers to Broadcom Inc.
+// and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcda
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// writeOnlySuffix and writeOnlyVersionSuffix name the write-only variant of a
+// secret argument and the attribute that triggers its re-application.
+const (
+	writeOnlySuffix        = "_wo"
+	writeOnlyVersionSuffix = "_wo_version"
+)
+
+// writeOnlySchema returns the schema of the write-only variant of the secret
+// argument name. The value is never persisted in the plan or the state.
+func writeOnlySchema(name string, description string) *schema.Schema {
+	return &schema.Schema{
+		Type: schema.TypeString,
+		Description: description + " The value is write-only and is not stored in the Terraform state. " +
+			"Requires Terraform 1.11 or later. Change `" + name + writeOnlyVersionSuffix + "` to apply a new value.",
+		Optional:      true,
+		Sensitive:     true,
+		WriteOnly:     true,
+		ConflictsWith: []string{name},
+	}
+}
+
+// writeOnlyVersionSchema returns the schema of the version attribute of the
+// write-only secret argument name.
+func writeOnlyVersionSchema(name string) *schema.Schema {
+	return &schema.Schema{
+		Type: schema.TypeInt,
+		Description: "The version of `" + name + writeOnlySuffix + "`. " +
+			"Since write-only values are not stored, change the version to apply a new value.",
+		Optional:     true,
+		RequiredWith: []string{name + writeOnlySuffix},
+	}
+}
+
+// getSecret returns the value of the secret argument name, or else of its
+// write-only variant, which is only available in the raw configuration.
+func getSecret(d *schema.ResourceData, name string) (string, error) {
+	if v := d.Get(name).(string); v != "" {
+		return v, nil
+	}
+
+	v, diags := d.GetRawConfigAt(cty.GetAttrPath(name + writeOnlySuffix))
+	if diags.HasError() {
+		return "", fmt.Errorf("error reading %s%s field: %v", name, writeOnlySuffix, diags)
+	}
+
+	if !v.Type().Equals(cty.String) || v.IsNull() || !v.IsKnown() {
+		return "", nil
+	}
+
+	return v.AsString(), nil
+}
+
+// hasSecretChange reports whether the secret argument name or the version of
+// its write-only variant has changed.
+func hasSecretChange(d *schema.ResourceData, name string) bool {
+	return d.HasChange(name) || d.HasChange(name+writeOnlyVersionSuffix)
+}